@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// defaultEventDuration is the duration of an added event, if not given
+const defaultEventDuration = 30 * time.Minute
+
+var (
+	attendees []string
+	withMeet  bool
+	quickAdd  bool
+)
+
+// timeOfDayLayouts are the accepted forms of the time of an event
+var timeOfDayLayouts = []string{"15:04", "3:04pm", "3pm"}
+
+// parseTimeOfDay sets the time of day on the given day
+func parseTimeOfDay(day time.Time, s string) (time.Time, error) {
+	for _, layout := range timeOfDayLayouts {
+		t, err := time.Parse(layout, strings.ToLower(s))
+		if err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+		}
+	}
+	return day, fmt.Errorf("unsupported time %q, use the form of 15:00 or 3pm", s)
+}
+
+// parseAddArgs parses the title, day expression, time and the optional
+// duration of an event
+func parseAddArgs(pointInTime time.Time, args []string) (string, time.Time, time.Duration, error) {
+	title := args[0]
+	day, _, err := parseDatetimeExpression(pointInTime, args[1])
+	if err != nil {
+		return title, day, 0, err
+	}
+	start, err := parseTimeOfDay(day, args[2])
+	if err != nil {
+		return title, start, 0, err
+	}
+	duration := defaultEventDuration
+	if len(args) > 3 {
+		duration, err = time.ParseDuration(args[3])
+		if err != nil {
+			return title, start, 0, err
+		}
+		if duration <= 0 {
+			return title, start, 0, fmt.Errorf("duration must be greater than 0")
+		}
+	}
+	return title, start, duration, nil
+}
+
+func newEvent(title string, start time.Time, duration time.Duration, emails []string, meet bool) *calendar.Event {
+	e := &calendar.Event{
+		Summary: title,
+		Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: start.Add(duration).Format(time.RFC3339)},
+	}
+	for _, email := range emails {
+		e.Attendees = append(e.Attendees, &calendar.EventAttendee{Email: email})
+	}
+	if meet {
+		e.ConferenceData = &calendar.ConferenceData{
+			CreateRequest: &calendar.CreateConferenceRequest{
+				RequestId:             newPlanId(start),
+				ConferenceSolutionKey: &calendar.ConferenceSolutionKey{Type: "hangoutsMeet"},
+			},
+		}
+	}
+	return e
+}
+
+// overlapping returns the busy events that overlap with the given period
+func overlapping(events []*calendar.Event, start, end time.Time) []*calendar.Event {
+	var conflicts []*calendar.Event
+	for _, e := range events {
+		if !isBusy(e) {
+			continue
+		}
+		s, en, err := eventTimes(e)
+		if err != nil {
+			continue
+		}
+		if s.Before(end) && en.After(start) {
+			conflicts = append(conflicts, e)
+		}
+	}
+	return conflicts
+}
+
+// addCmd creates a single event
+var addCmd = &cobra.Command{
+	Use:   "add TITLE DAY EXPRESSION TIME [DURATION]",
+	Short: "Add an event",
+	Long: fmt.Sprintf(`Add an event on a day and time, for %s if no duration is given.
+With --quick the text is parsed by Google Calendar, like its quick add box.`, defaultEventDuration),
+	Example: `$ calgo add "1:1 with dana" th 15:00 30m --with dana@example.com --meet
+$ calgo add "planning" m 10:00 1h --with team # an alias of the aliases file
+$ calgo add --quick "lunch with dana tomorrow at noon"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if quickAdd {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		if len(args) < 3 || len(args) > 4 {
+			return fmt.Errorf("expected a title, a day expression, a time and an optional duration")
+		}
+		if err := validateDateExpressionArgs(args[1:]); err != nil {
+			return err
+		}
+		_, err := parseRepeat(repeat, repeatCount)
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := google_calendar.Service()
+		if quickAdd {
+			changed(calendarID)
+			var created *calendar.Event
+			err := withRetry(func() (err error) {
+				created, err = srv.Events.QuickAdd(calendarID, args[0]).Do()
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("unable to add event: %w", err)
+			}
+			fmt.Print(eventString(created))
+			return nil
+		}
+
+		title, start, duration, err := parseAddArgs(clock(), args)
+		if err != nil {
+			return err
+		}
+		aliases, err := readAliases()
+		if err != nil {
+			return err
+		}
+		emails, err := expandAttendees(attendees, aliases)
+		if err != nil {
+			return err
+		}
+		// recurring events need the time zone, which is checked before
+		// adding anything
+		rrule, _ := parseRepeat(repeat, repeatCount)
+		var timeZone string
+		if rrule != "" {
+			if timeZone, err = localTimeZone(); err != nil {
+				return err
+			}
+		}
+		end := start.Add(duration)
+		events, err := srv.Events.List(calendarID).
+			ShowDeleted(false).
+			SingleEvents(true).
+			TimeMin(start.Format(time.RFC3339)).
+			TimeMax(end.Format(time.RFC3339)).
+			OrderBy(sortField).
+			Do()
+		if err != nil {
+			return fmt.Errorf("unable to retrieve events: %w", err)
+		}
+		if conflicts := overlapping(events.Items, start, end); len(conflicts) > 0 {
+			fmt.Printf("Conflicts with %d events:\n", len(conflicts))
+			for _, c := range conflicts {
+				fmt.Print(eventString(c))
+			}
+			if interactive {
+				add, err := confirm("Add anyway?", false)
+				if err != nil || !add {
+					return err
+				}
+			}
+		}
+
+		event := newEvent(title, start, duration, emails, withMeet)
+		if rrule != "" {
+			makeRecurring(event, rrule, timeZone)
+		}
+		changed(calendarID)
+		created, err := insertWithRetry(event, func(event *calendar.Event) (*calendar.Event, error) {
+			call := srv.Events.Insert(calendarID, event).ConferenceDataVersion(1)
+			if len(event.Attendees) > 0 {
+				call = call.SendUpdates("all")
+			}
+			return call.Do()
+		})
+		if err != nil {
+			return fmt.Errorf("unable to add event: %w", err)
+		}
+		fmt.Printf("%s %s", start.Format(dayFormat), eventString(created))
+		if created.HangoutLink != "" {
+			fmt.Printf("%20s%s\n", "", created.HangoutLink)
+		}
+		return nil
+	},
+}
+
+func init() {
+	addCmd.Flags().StringSliceVar(&attendees, "with", nil, "email or alias of attendees, repeat or separate with commas for several")
+	addCmd.RegisterFlagCompletionFunc("with", completeAttendees)
+	addCmd.Flags().BoolVar(&withMeet, "meet", false, "add a Google Meet link")
+	addCmd.Flags().BoolVar(&quickAdd, "quick", false, "let Google Calendar parse the event from the text")
+	addCmd.Flags().BoolVar(&interactive, "interactive", true, "Ask before adding a conflicting event")
+	addRepeatFlags(addCmd)
+	rootCmd.AddCommand(addCmd)
+}
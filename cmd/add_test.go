@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddArgs(t *testing.T) {
+	// Monday
+	now := at(10, 0)
+	cases := []struct {
+		args          []string
+		wantedStart   time.Time
+		wantedLasting time.Duration
+		wantedErr     bool
+	}{
+		{args: []string{"1:1", "th", "15:00", "45m"}, wantedStart: time.Date(2023, 9, 28, 15, 0, 0, 0, time.UTC), wantedLasting: 45 * time.Minute},
+		{args: []string{"1:1", "+1", "3pm"}, wantedStart: time.Date(2023, 9, 26, 15, 0, 0, 0, time.UTC), wantedLasting: defaultEventDuration},
+		{args: []string{"1:1", "m", "9:30AM"}, wantedStart: time.Date(2023, 9, 25, 9, 30, 0, 0, time.UTC), wantedLasting: defaultEventDuration},
+		{args: []string{"1:1", "m", "noon"}, wantedErr: true},
+		{args: []string{"1:1", "m", "12:00", "0m"}, wantedErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.args[2], func(t *testing.T) {
+			title, start, duration, err := parseAddArgs(now, tc.args)
+			if tc.wantedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "1:1", title)
+			assert.Equal(t, tc.wantedStart, start)
+			assert.Equal(t, tc.wantedLasting, duration)
+		})
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	e := newEvent("1:1 with dana", at(15, 0), 30*time.Minute, []string{"dana@example.com"}, true)
+	assert.Equal(t, "dana@example.com", e.Attendees[0].Email)
+	assert.Equal(t, "hangoutsMeet", e.ConferenceData.CreateRequest.ConferenceSolutionKey.Type)
+	assert.False(t, isCalgoEvent(e), "unplan must not remove added events")
+}
+
+func TestOverlapping(t *testing.T) {
+	events := existingMeetings(
+		[2]time.Time{at(14, 0), at(15, 0)},
+		[2]time.Time{at(15, 15), at(16, 0)},
+		[2]time.Time{at(16, 0), at(17, 0)},
+	)
+	conflicts := overlapping(events, at(15, 0), at(16, 0))
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "meeting 2", conflicts[0].Summary)
+}
@@ -0,0 +1,382 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/people/v1"
+)
+
+// peopleCacheTTL is how long the contacts fetched from the People API are
+// used before fetching them again
+const peopleCacheTTL = 24 * time.Hour
+
+// contact is an attendee to complete, out of the past events, the contacts
+// or the directory
+type contact struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+	// meetings is the number of past events with the contact, the
+	// frequent ones are completed first
+	meetings int
+}
+
+// addressBook completes and expands attendees, group aliases expand into
+// several attendees
+type addressBook struct {
+	contacts map[string]*contact
+	aliases  map[string][]string
+}
+
+func newAddressBook() *addressBook {
+	return &addressBook{contacts: map[string]*contact{}, aliases: map[string][]string{}}
+}
+
+func (b *addressBook) add(email, name string) *contact {
+	key := strings.ToLower(email)
+	c, ok := b.contacts[key]
+	if !ok {
+		c = &contact{Email: email}
+		b.contacts[key] = c
+	}
+	if c.Name == "" {
+		c.Name = name
+	}
+	return c
+}
+
+// addEvents adds the attendees of past events
+func (b *addressBook) addEvents(events []*calendar.Event) {
+	for _, e := range events {
+		for _, a := range e.Attendees {
+			if a.Self || a.Resource || a.Email == "" {
+				continue
+			}
+			b.add(a.Email, a.DisplayName).meetings++
+		}
+	}
+}
+
+func (b *addressBook) addContacts(contacts []contact) {
+	for _, c := range contacts {
+		b.add(c.Email, c.Name)
+	}
+}
+
+// complete returns the aliases and the emails starting with the given
+// prefix, or of a contact with a name starting with it, the frequent
+// contacts first
+func (b *addressBook) complete(toComplete string) []string {
+	var matches []string
+	for _, c := range b.match(toComplete) {
+		matches = append(matches, c.Email)
+	}
+	return matches
+}
+
+// completions are the matches along with a description, for the shell
+// completion
+func (b *addressBook) completions(toComplete string) []string {
+	var completions []string
+	for _, c := range b.match(toComplete) {
+		if c.Name == "" {
+			completions = append(completions, c.Email)
+			continue
+		}
+		completions = append(completions, c.Email+"\t"+c.Name)
+	}
+	return completions
+}
+
+func (b *addressBook) match(toComplete string) []contact {
+	prefix := strings.ToLower(toComplete)
+	var aliases []contact
+	for name, members := range b.aliases {
+		if strings.HasPrefix(strings.ToLower(name), prefix) {
+			aliases = append(aliases, contact{Email: name, Name: strings.Join(members, ", ")})
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Email < aliases[j].Email })
+
+	var contacts []contact
+	for key, c := range b.contacts {
+		if strings.HasPrefix(key, prefix) || namePrefix(c.Name, prefix) {
+			contacts = append(contacts, *c)
+		}
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		if contacts[i].meetings != contacts[j].meetings {
+			return contacts[i].meetings > contacts[j].meetings
+		}
+		return contacts[i].Email < contacts[j].Email
+	})
+	return append(aliases, contacts...)
+}
+
+// namePrefix tells if any of the words of the name starts with the prefix
+func namePrefix(name, prefix string) bool {
+	for _, word := range strings.Fields(strings.ToLower(name)) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandAttendees replaces the aliases with their members, aliases may
+// include other aliases. Anything else must be an email address.
+func expandAttendees(values []string, aliases map[string][]string) ([]string, error) {
+	var emails []string
+	seen := map[string]bool{}
+	var expand func(values []string, expanding []string) error
+	expand = func(values []string, expanding []string) error {
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			if members, ok := aliases[v]; ok {
+				if contains(expanding, v) {
+					return fmt.Errorf("alias %s includes itself", v)
+				}
+				if err := expand(members, append(expanding, v)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := validateEmail(v); err != nil {
+				return fmt.Errorf("%q is neither an alias nor an email address", v)
+			}
+			if !seen[strings.ToLower(v)] {
+				seen[strings.ToLower(v)] = true
+				emails = append(emails, v)
+			}
+		}
+		return nil
+	}
+	return emails, expand(values, nil)
+}
+
+// aliasesPath is the aliases file, set with aliases in the config
+func aliasesPath() (string, error) {
+	if path := viper.GetString("aliases"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "calgo", "aliases"), nil
+}
+
+// parseAliases reads lines in the form of 'team: a@example.com, b@example.com',
+// lines starting with # are comments
+func parseAliases(r io.Reader) (map[string][]string, error) {
+	aliases := map[string][]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, members, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t@,") {
+			return nil, fmt.Errorf("line %d: expected 'alias: email, email', got %q", n, line)
+		}
+		for _, m := range strings.Split(members, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				aliases[name] = append(aliases[name], m)
+			}
+		}
+		if len(aliases[name]) == 0 {
+			return nil, fmt.Errorf("line %d: alias %s has no members", n, name)
+		}
+	}
+	return aliases, scanner.Err()
+}
+
+// readAliases reads the aliases file, which is optional
+func readAliases() (map[string][]string, error) {
+	path, err := aliasesPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string][]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	aliases, err := parseAliases(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aliases file %s: %w", path, err)
+	}
+	return aliases, nil
+}
+
+// fetchPeople reads the contacts, the other contacts, i.e people emailed
+// before, and the directory of the organization. Sources calgo was not
+// granted are skipped.
+func fetchPeople(service *people.Service) ([]contact, error) {
+	var contacts []contact
+	addPersons := func(persons []*people.Person) {
+		for _, p := range persons {
+			name := ""
+			if len(p.Names) > 0 {
+				name = p.Names[0].DisplayName
+			}
+			for _, e := range p.EmailAddresses {
+				contacts = append(contacts, contact{Email: e.Value, Name: name})
+			}
+		}
+	}
+	const fields = "names,emailAddresses"
+	ctx := context.Background()
+	sources := []func() error{
+		func() error {
+			return service.People.Connections.List("people/me").PersonFields(fields).PageSize(1000).
+				Pages(ctx, func(r *people.ListConnectionsResponse) error {
+					addPersons(r.Connections)
+					return nil
+				})
+		},
+		func() error {
+			return service.OtherContacts.List().ReadMask(fields).PageSize(1000).
+				Pages(ctx, func(r *people.ListOtherContactsResponse) error {
+					addPersons(r.OtherContacts)
+					return nil
+				})
+		},
+		func() error {
+			return service.People.ListDirectoryPeople().ReadMask(fields).PageSize(1000).
+				Sources("DIRECTORY_SOURCE_TYPE_DOMAIN_PROFILE").
+				Pages(ctx, func(r *people.ListDirectoryPeopleResponse) error {
+					addPersons(r.People)
+					return nil
+				})
+		},
+	}
+	for _, source := range sources {
+		err := withRetry(source)
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden {
+			// not granted with calgo init --contacts, or not a workspace
+			// account for the directory
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return contacts, nil
+}
+
+// peopleCachePath keeps the fetched contacts next to the events cache
+func peopleCachePath() (string, error) {
+	path, err := cachePath()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, ".db") + "-contacts.json", nil
+}
+
+// cachedPeople returns the contacts fetched within the peopleCacheTTL, or
+// fetches them again unless offline. --refresh fetches them anyway.
+func cachedPeople() ([]contact, error) {
+	path, err := peopleCachePath()
+	if err != nil {
+		return nil, err
+	}
+	var contacts []contact
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &contacts); err != nil {
+			return nil, fmt.Errorf("failed parsing the contacts cache %s: %w", path, err)
+		}
+	}
+	fresh := statErr == nil && time.Since(info.ModTime()) < peopleCacheTTL
+	if fresh && !refresh || offline {
+		return contacts, nil
+	}
+	contacts, err = fetchPeople(google_calendar.PeopleService())
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(contacts)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	return contacts, os.WriteFile(path, b, 0600)
+}
+
+// loadAddressBook builds the address book out of the aliases file, the
+// attendees of the cached past events and the contacts. Sources which fail
+// are left out, completion works with whatever is available.
+func loadAddressBook(service *calendar.Service) *addressBook {
+	b := newAddressBook()
+	aliases, err := readAliases()
+	if err != nil {
+		log.Printf("not completing aliases: %v\n", err)
+	} else {
+		b.aliases = aliases
+	}
+	now := clock()
+	events, err := cachedEvents(service, calendarID, now.AddDate(0, 0, -cacheDaysBack), now)
+	if err != nil {
+		log.Printf("not completing past attendees: %v\n", err)
+	} else {
+		b.addEvents(events)
+	}
+	contacts, err := cachedPeople()
+	if err != nil {
+		log.Printf("not completing contacts: %v\n", err)
+	} else {
+		b.addContacts(contacts)
+	}
+	return b
+}
+
+// completeAttendees is the shell completion of a flag of attendees, which
+// completes the last of the comma separated values
+func completeAttendees(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// completion reads only what is cached, and must not write to the
+	// terminal
+	offline = true
+	log.SetOutput(io.Discard)
+	head := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		head, toComplete = toComplete[:i+1], toComplete[i+1:]
+	}
+	var completions []string
+	for _, c := range loadAddressBook(nil).completions(toComplete) {
+		completions = append(completions, head+c)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
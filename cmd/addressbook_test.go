@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestAddressBookComplete(t *testing.T) {
+	book := newAddressBook()
+	book.aliases["rgo-team"] = []string{"dana@example.com", "rgolan@redhat.com"}
+	book.addEvents([]*calendar.Event{
+		{Attendees: []*calendar.EventAttendee{
+			{Email: "me@example.com", Self: true},
+			{Email: "rgolan@redhat.com"},
+			{Email: "room@example.com", Resource: true},
+		}},
+		{Attendees: []*calendar.EventAttendee{
+			{Email: "dana@example.com", DisplayName: "Dana Scully"},
+			{Email: "rgolan@redhat.com"},
+		}},
+	})
+	book.addContacts([]contact{
+		{Email: "rgomez@example.com", Name: "Rita Gomez"},
+		{Email: "Dana@example.com", Name: "Dana S"},
+	})
+
+	// aliases come first, then the frequent attendees
+	assert.Equal(t, []string{"rgo-team", "rgolan@redhat.com", "rgomez@example.com"}, book.complete("rgo"))
+	assert.Equal(t, []string{"dana@example.com"}, book.complete("scu"))
+	assert.Equal(t, []string{"rgomez@example.com"}, book.complete("Gom"))
+	assert.Nil(t, book.complete("nobody"))
+	assert.Equal(t, []string{
+		"rgo-team\tdana@example.com, rgolan@redhat.com",
+		"rgolan@redhat.com",
+		"rgomez@example.com\tRita Gomez",
+	}, book.completions("rgo"))
+}
+
+func TestExpandAttendees(t *testing.T) {
+	aliases := map[string][]string{
+		"team":  {"dana@example.com", "joe@example.com"},
+		"leads": {"team", "ann@example.com"},
+		"loop":  {"dana@example.com", "loop"},
+	}
+	emails, err := expandAttendees([]string{"leads", "Dana@example.com", "bob@example.com"}, aliases)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dana@example.com", "joe@example.com", "ann@example.com", "bob@example.com"}, emails)
+
+	_, err = expandAttendees([]string{"loop"}, aliases)
+	assert.EqualError(t, err, "alias loop includes itself")
+	_, err = expandAttendees([]string{"tema"}, aliases)
+	assert.EqualError(t, err, `"tema" is neither an alias nor an email address`)
+}
+
+func TestParseAliases(t *testing.T) {
+	aliases, err := parseAliases(strings.NewReader(`
+# the whole team
+team: a@example.com, b@example.com
+dana:dana@example.com
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"team": {"a@example.com", "b@example.com"},
+		"dana": {"dana@example.com"},
+	}, aliases)
+
+	for _, content := range []string{"team a@example.com", "my team: a@example.com", "team:", ": a@example.com"} {
+		_, err := parseAliases(strings.NewReader(content))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestReadAliases(t *testing.T) {
+	defer viper.Reset()
+	path := filepath.Join(t.TempDir(), "aliases")
+	viper.Set("aliases", path)
+
+	aliases, err := readAliases()
+	assert.NoError(t, err)
+	assert.Empty(t, aliases, "the aliases file is optional")
+
+	assert.NoError(t, os.WriteFile(path, []byte("team: a@example.com\nbroken\n"), 0600))
+	_, err = readAliases()
+	assert.ErrorContains(t, err, "line 2")
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pretty "github.com/jedib0t/go-pretty/v6/text"
+	"google.golang.org/api/calendar/v3"
+)
+
+var allCalendars bool
+
+// fetchWorkers is the number of calendars fetched concurrently
+const fetchWorkers = 4
+
+// calendarColors tell apart the events of several calendars, in the order
+// of the calendars
+var calendarColors = []pretty.Color{pretty.FgCyan, pretty.FgMagenta, pretty.FgYellow, pretty.FgBlue, pretty.FgGreen, pretty.FgRed}
+
+// calendarLabels are the short names of the listed calendars and their
+// colors, by calendar id
+type calendarLabels map[string]calendarLabel
+
+type calendarLabel struct {
+	name  string
+	color pretty.Color
+}
+
+// newCalendarLabels names the calendars by their summary if known, by the
+// local part of their id otherwise
+func newCalendarLabels(ids []string, summaries map[string]string) calendarLabels {
+	labels := calendarLabels{}
+	for i, id := range ids {
+		name := summaries[id]
+		if name == "" {
+			name, _, _ = strings.Cut(id, "@")
+		}
+		labels[id] = calendarLabel{name: name, color: calendarColors[i%len(calendarColors)]}
+	}
+	return labels
+}
+
+func (l calendarLabels) prefix(id string) string {
+	label, ok := l[id]
+	if !ok {
+		return ""
+	}
+	return label.color.Sprint("["+label.name+"]") + " "
+}
+
+// agendaCalendars returns the calendars to list, either the selected ones of
+// the calendar list, the --calendar ones or the --calendar-id
+func agendaCalendars(service *calendar.Service) ([]string, calendarLabels, error) {
+	if !allCalendars {
+		ids := listCalendars
+		if len(ids) == 0 {
+			ids = []string{calendarID}
+		}
+		return ids, newCalendarLabels(ids, nil), nil
+	}
+	var ids []string
+	summaries := map[string]string{}
+	err := service.CalendarList.List().Pages(context.Background(), func(page *calendar.CalendarList) error {
+		for _, c := range page.Items {
+			if !c.Selected {
+				continue
+			}
+			ids = append(ids, c.Id)
+			summaries[c.Id] = c.SummaryOverride
+			if summaries[c.Id] == "" {
+				summaries[c.Id] = c.Summary
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to retrieve calendars: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil, fmt.Errorf("no calendar is selected in the calendar list")
+	}
+	return ids, newCalendarLabels(ids, summaries), nil
+}
+
+// fetchEvents lists the events of the calendars from tmin to tmax, merged
+// by their start time, along with the calendar of each event. The calendars
+// are fetched concurrently, and an event invited on several calendars is
+// kept only once, on the first of them. The query is searched by the
+// calendar API, or in the cache when offline.
+func fetchEvents(service *calendar.Service, calendars []string, tmin, tmax time.Time, query string) ([]*calendar.Event, map[*calendar.Event]string, error) {
+	fetched := make([][]*calendar.Event, len(calendars))
+	errs := make([]error, len(calendars))
+	runPool(len(calendars), fetchWorkers, 0, func(i int, _ func()) {
+		if query != "" && !offline {
+			fetched[i], errs[i] = apiEvents(service, calendars[i], tmin, tmax, query)
+			return
+		}
+		events, err := cachedEvents(service, calendars[i], tmin, tmax)
+		for _, e := range events {
+			if matchesQuery(e, query) {
+				fetched[i] = append(fetched[i], e)
+			}
+		}
+		errs[i] = err
+	})
+
+	merged := newEvents()
+	calendarOf := map[*calendar.Event]string{}
+	seen := map[string]bool{}
+	for i, events := range fetched {
+		if errs[i] != nil {
+			return nil, nil, fmt.Errorf("unable to retrieve the events of %s: %w", calendars[i], errs[i])
+		}
+		for _, e := range events {
+			if key := dedupeKey(e); key != "" {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			calendarOf[e] = calendars[i]
+			merged.insert(e)
+		}
+	}
+	return merged.items(), calendarOf, nil
+}
+
+// dedupeKey identifies the same event on several calendars. The instances
+// of a recurring event share their iCalUID, so the start tells them apart.
+func dedupeKey(e *calendar.Event) string {
+	if e.ICalUID == "" {
+		return ""
+	}
+	start := e.Start
+	if e.OriginalStartTime != nil {
+		start = e.OriginalStartTime
+	}
+	if start == nil {
+		return e.ICalUID
+	}
+	return e.ICalUID + "/" + start.DateTime + start.Date
+}
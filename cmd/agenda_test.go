@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// listStandIn serves the events of each calendar and the calendar list,
+// and records the queries
+type listStandIn struct {
+	mu        sync.Mutex
+	events    map[string][]*calendar.Event
+	calendars []*calendar.CalendarListEntry
+	queries   []url.Values
+	// pageSize splits the events to pages, unless zero
+	pageSize int
+}
+
+func (l *listStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/calendarList") {
+		_ = json.NewEncoder(w).Encode(&calendar.CalendarList{Items: l.calendars})
+		return
+	}
+	// the path is /calendars/{id}/events
+	parts := strings.Split(r.URL.Path, "/")
+	id, _ := url.PathUnescape(parts[len(parts)-2])
+	l.mu.Lock()
+	l.queries = append(l.queries, r.URL.Query())
+	l.mu.Unlock()
+	items := l.events[id]
+	page := &calendar.Events{Items: items}
+	if l.pageSize > 0 {
+		from, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		to := from + l.pageSize
+		if to < len(items) {
+			page.NextPageToken = strconv.Itoa(to)
+		} else {
+			to = len(items)
+		}
+		page.Items = items[from:to]
+	}
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+func listStandInService(t *testing.T, standIn *listStandIn) *calendar.Service {
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	srv, err := calendar.NewService(context.Background(),
+		option.WithEndpoint(server.URL+"/"),
+		option.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+	return srv
+}
+
+func TestFetchEventsOfSeveralCalendars(t *testing.T) {
+	isolatedCache(t)
+	primary := existingMeetings([2]time.Time{at(9, 0), at(10, 0)}, [2]time.Time{at(14, 0), at(15, 0)})
+	team := existingMeetings([2]time.Time{at(11, 0), at(12, 0)})
+	team[0].Id = "oncall"
+	standIn := &listStandIn{events: map[string][]*calendar.Event{"primary": primary, "team@example.com": team}}
+	srv := listStandInService(t, standIn)
+
+	events, calendarOf, err := fetchEvents(srv, []string{"primary", "team@example.com"}, at(0, 0), at(23, 0), "review")
+
+	assert.NoError(t, err)
+	var ids, calendars []string
+	for _, e := range events {
+		ids = append(ids, e.Id)
+		calendars = append(calendars, calendarOf[e])
+	}
+	assert.Equal(t, []string{"1", "oncall", "2"}, ids)
+	assert.Equal(t, []string{"primary", "team@example.com", "primary"}, calendars)
+	for _, q := range standIn.queries {
+		assert.Equal(t, "review", q.Get("q"))
+	}
+}
+
+func TestFetchEventsDedupesByICalUID(t *testing.T) {
+	isolatedCache(t)
+	primary := existingMeetings([2]time.Time{at(9, 0), at(10, 0)}, [2]time.Time{at(9, 0).AddDate(0, 0, 1), at(10, 0).AddDate(0, 0, 1)})
+	primary[0].ICalUID = "standup@google.com"
+	primary[1].ICalUID = "standup@google.com"
+	team := existingMeetings([2]time.Time{at(9, 0), at(10, 0)})
+	team[0].Id = "team-copy"
+	team[0].ICalUID = "standup@google.com"
+	standIn := &listStandIn{events: map[string][]*calendar.Event{"primary": primary, "team": team}}
+	srv := listStandInService(t, standIn)
+
+	events, calendarOf, err := fetchEvents(srv, []string{"primary", "team"}, at(0, 0), at(23, 0), "")
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 2, "instances of a recurring event are not duplicates")
+	for _, e := range events {
+		assert.Equal(t, "primary", calendarOf[e])
+	}
+}
+
+func TestAgendaCalendars(t *testing.T) {
+	standIn := &listStandIn{calendars: []*calendar.CalendarListEntry{
+		{Id: "me@example.com", Summary: "me", Selected: true},
+		{Id: "holidays@group.calendar.google.com", Summary: "Holidays", SummaryOverride: "holidays"},
+		{Id: "oncall@group.calendar.google.com", Summary: "On call", Selected: true},
+	}}
+	srv := listStandInService(t, standIn)
+	t.Cleanup(func() { allCalendars = false })
+
+	allCalendars = true
+	ids, labels, err := agendaCalendars(srv)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"me@example.com", "oncall@group.calendar.google.com"}, ids)
+	assert.Contains(t, labels.prefix("oncall@group.calendar.google.com"), "[On call]")
+
+	allCalendars = false
+	listCalendars = []string{"team@example.com"}
+	t.Cleanup(func() { listCalendars = nil })
+	ids, labels, err = agendaCalendars(srv)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"team@example.com"}, ids)
+	assert.Contains(t, labels.prefix("team@example.com"), "[team]")
+}
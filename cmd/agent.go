@@ -0,0 +1,367 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	syncInterval  time.Duration
+	notifyBefore  time.Duration
+	notifyCommand string
+	// runningAgent is set in the agent process, which serves the reads of
+	// other calgo processes
+	runningAgent bool
+)
+
+// agentTick is how often the agent looks for something to notify about
+const agentTick = 30 * time.Second
+
+// agentTimeout is how long calgo waits for the agent before going to the API
+const agentTimeout = 2 * time.Second
+
+// notice is a notification of the agent, the key makes sure it is sent once
+type notice struct {
+	key   string
+	title string
+	body  string
+}
+
+// agentNotices returns the notifications due between last and now: meetings
+// starting within before, calgo focus blocks beginning or ending, and
+// invitations waiting for a response which conflict with a focus block.
+// Notices in seen are not sent again.
+func agentNotices(events []*calendar.Event, last, now time.Time, before time.Duration, seen map[string]bool) []notice {
+	var focus []*calendar.Event
+	for _, e := range events {
+		if isCalgoFocusEvent(e) {
+			focus = append(focus, e)
+		}
+	}
+	var notices []notice
+	add := func(n notice) {
+		if !seen[n.key] {
+			seen[n.key] = true
+			notices = append(notices, n)
+		}
+	}
+	within := func(t time.Time) bool {
+		return t.After(last) && !t.After(now)
+	}
+	for _, e := range events {
+		start, end, err := eventTimes(e)
+		if err != nil || e.Start.DateTime == "" {
+			continue
+		}
+		key := e.Id + "/" + start.Format(time.RFC3339)
+		switch {
+		case isCalgoFocusEvent(e):
+			if within(start) {
+				add(notice{key: "focus-start/" + key, title: "Focus time started", body: fmt.Sprintf("%s until %s", e.Summary, end.Format(time.Kitchen))})
+			}
+			if within(end) {
+				add(notice{key: "focus-end/" + key, title: "Focus time ended", body: e.Summary})
+			}
+		case responseStatus(e) == "needsAction":
+			if conflicts := overlapping(focus, start, end); len(conflicts) > 0 {
+				add(notice{key: "invite/" + key, title: "Invitation conflicts with focus time", body: fmt.Sprintf("%s %s - %s", e.Summary, start.Format(dayFormat+" "+time.Kitchen), end.Format(time.Kitchen))})
+			}
+		case isBusy(e) && isAccepted(e) && !isFocusEvent(e) && e.EventType != "outOfOffice":
+			if start.After(now) && !start.After(now.Add(before)) {
+				body := e.Location
+				if e.HangoutLink != "" {
+					body = e.HangoutLink
+				}
+				add(notice{key: "meeting/" + key, title: fmt.Sprintf("%s at %s", e.Summary, start.Format(time.Kitchen)), body: body})
+			}
+		}
+	}
+	return notices
+}
+
+func controlSocketPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "calgo", "agent.sock"), nil
+}
+
+// listenControl listens on the control socket, replacing the socket of an
+// agent which is no longer running
+func listenControl() (net.Listener, error) {
+	path, err := controlSocketPath()
+	if err != nil {
+		return nil, err
+	}
+	if conn, err := net.DialTimeout("unix", path, agentTimeout); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("an agent is already running on %s", path)
+	}
+	_ = os.Remove(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return listener, os.Chmod(path, 0600)
+}
+
+// agentHandler serves the cached events to other calgo processes, as of the
+// last sync of the agent. The calendars are synced again when a client tells
+// the agent it changed them, or asks to refresh.
+func agentHandler(service *calendar.Service) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		tmin, err := time.Parse(time.RFC3339, q.Get("timeMin"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tmax, err := time.Parse(time.RFC3339, q.Get("timeMax"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		events, err := readCache(service, q.Get("calendarId"), tmin, tmax, q.Get("offline") == "true", q.Get("refresh") == "true")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(events)
+	})
+	mux.HandleFunc("/expire", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "expire is a POST", http.StatusMethodNotAllowed)
+			return
+		}
+		c, err := getCache()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.expire(r.URL.Query()["calendarId"]...)
+	})
+	return mux
+}
+
+var errNoAgent = errors.New("no agent is running")
+
+// agentRequest sends a request to the agent over the control socket
+func agentRequest(method, path string, q url.Values) (*http.Response, error) {
+	socket, err := controlSocketPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(socket); err != nil {
+		return nil, errNoAgent
+	}
+	client := &http.Client{
+		Timeout: agentTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				conn, err := (&net.Dialer{}).DialContext(ctx, "unix", socket)
+				if err != nil {
+					// the socket of an agent which is no longer running
+					return nil, errNoAgent
+				}
+				return conn, nil
+			},
+		},
+	}
+	req, err := http.NewRequest(method, "http://agent"+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("agent responded %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// agentEvents asks the agent for the events of the calendar
+func agentEvents(calendarId string, tmin, tmax time.Time) ([]*calendar.Event, error) {
+	q := url.Values{}
+	q.Set("calendarId", calendarId)
+	q.Set("timeMin", tmin.Format(time.RFC3339))
+	q.Set("timeMax", tmax.Format(time.RFC3339))
+	q.Set("offline", strconv.FormatBool(offline))
+	q.Set("refresh", strconv.FormatBool(refresh))
+	resp, err := agentRequest(http.MethodGet, "/events", q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var events []*calendar.Event
+	return events, json.NewDecoder(resp.Body).Decode(&events)
+}
+
+var (
+	changedMu sync.Mutex
+	// changedCalendars are the calendars this process wrote to
+	changedCalendars = map[string]bool{}
+)
+
+// changed records a write to the calendar, for the agent to sync it again.
+// It is called before the write, a write which failed may still be applied.
+func changed(calendarId string) {
+	changedMu.Lock()
+	defer changedMu.Unlock()
+	changedCalendars[calendarId] = true
+}
+
+// tellAgent tells the agent which calendars this process changed, so the
+// next reads sync them again
+func tellAgent() {
+	changedMu.Lock()
+	defer changedMu.Unlock()
+	if len(changedCalendars) == 0 || runningAgent {
+		return
+	}
+	q := url.Values{}
+	for id := range changedCalendars {
+		q.Add("calendarId", id)
+	}
+	resp, err := agentRequest(http.MethodPost, "/expire", q)
+	if err != nil {
+		if !errors.Is(err, errNoAgent) {
+			log.Printf("unable to tell the agent about the changes: %v\n", err)
+		}
+		return
+	}
+	resp.Body.Close()
+	changedCalendars = map[string]bool{}
+}
+
+// expire makes the next read sync the given calendars again, all of them
+// if none is given
+func (c *eventCache) expire(calendarIds ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(calendarIds) == 0 {
+		c.synced = map[string]bool{}
+		return
+	}
+	for _, id := range calendarIds {
+		delete(c.synced, id)
+	}
+}
+
+// runAgent syncs the cache every syncInterval and sends the notices, until
+// the context is done
+func runAgent(ctx context.Context, service *calendar.Service, notify notifier) error {
+	runningAgent = true
+	c, err := getCache()
+	if err != nil {
+		return err
+	}
+	listener, err := listenControl()
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: agentHandler(service)}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("control socket failed: %v\n", err)
+		}
+	}()
+	defer server.Close()
+
+	ticker := time.NewTicker(agentTick)
+	defer ticker.Stop()
+	seen := map[string]bool{}
+	last := clock()
+	var nextSync time.Time
+	for {
+		now := clock()
+		if !now.Before(nextSync) {
+			c.expire()
+			nextSync = now.Add(syncInterval)
+		}
+		events, err := cachedEvents(service, calendarID, midnight(now), midnight(now).AddDate(0, 0, 2))
+		if err != nil {
+			log.Printf("failed reading events: %v\n", err)
+		}
+		for _, n := range agentNotices(events, last, now, notifyBefore, seen) {
+			if err := notify(n.title, n.body); err != nil {
+				log.Printf("failed notifying %q: %v\n", n.title, err)
+			}
+		}
+		last = now
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// agentCmd runs in the background, syncing and notifying
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Sync in the background and notify about meetings and focus time",
+	Long: `Run in the background, sync the events periodically and send desktop
+notifications before meetings, when calgo focus time begins and ends, and when
+an invitation conflicts with focus time. Notifications go to the freedesktop
+notifications service, or to --notify-command.
+
+Other calgo commands read the events from the agent while it runs, and tell
+it to sync the calendars they change.`,
+	Example: `$ calgo agent &
+$ calgo agent --notify-before 10m --notify-command 'notify-send "$CALGO_TITLE" "$CALGO_BODY"'`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if syncInterval < agentTick {
+			return fmt.Errorf("--sync-interval must be at least %s", agentTick)
+		}
+		if notifyBefore < 0 {
+			return fmt.Errorf("--notify-before must not be negative")
+		}
+		return cobra.NoArgs(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		notify := commandNotifier(notifyCommand)
+		if notifyCommand == "" {
+			var err error
+			notify, err = dbusNotifier()
+			if err != nil {
+				return err
+			}
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return runAgent(ctx, google_calendar.Service(), notify)
+	},
+}
+
+func init() {
+	agentCmd.Flags().DurationVar(&syncInterval, "sync-interval", 5*time.Minute, "how often to sync the events")
+	agentCmd.Flags().DurationVar(&notifyBefore, "notify-before", 5*time.Minute, "how long before a meeting to notify")
+	agentCmd.Flags().StringVar(&notifyCommand, "notify-command", "", "shell command to notify with, the notification is in $CALGO_TITLE and $CALGO_BODY")
+	rootCmd.AddCommand(agentCmd)
+}
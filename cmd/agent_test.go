@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func noticeTitles(notices []notice) []string {
+	var titles []string
+	for _, n := range notices {
+		titles = append(titles, n.title)
+	}
+	return titles
+}
+
+func TestAgentNotices(t *testing.T) {
+	meetings := existingMeetings(
+		[2]time.Time{at(9, 0), at(10, 0)},
+		[2]time.Time{at(11, 0), at(12, 0)},
+	)
+	meetings[1].Attendees = []*calendar.EventAttendee{{Self: true, ResponseStatus: "needsAction"}}
+	focus := committedFocusEvent("focus", at(11, 0), 2*time.Hour)
+	events := []*calendar.Event{meetings[0], meetings[1], focus}
+	seen := map[string]bool{}
+
+	notices := agentNotices(events, at(8, 54), at(8, 55), 5*time.Minute, seen)
+	assert.Equal(t, []string{"meeting 1 at 9:00AM", "Invitation conflicts with focus time"}, noticeTitles(notices))
+
+	notices = agentNotices(events, at(8, 55), at(8, 56), 5*time.Minute, seen)
+	assert.Empty(t, notices, "notices are sent once")
+
+	notices = agentNotices(events, at(10, 59), at(11, 0), 5*time.Minute, seen)
+	assert.Equal(t, []string{"Focus time started"}, noticeTitles(notices))
+
+	notices = agentNotices(events, at(12, 59), at(13, 0), 5*time.Minute, seen)
+	assert.Equal(t, []string{"Focus time ended"}, noticeTitles(notices))
+}
+
+func TestAgentControlSocket(t *testing.T) {
+	isolatedCache(t)
+	defer func(c func() time.Time) { clock = c }(clock)
+	clock = fixedClock(at(7, 0))
+	defer func() { runningAgent = false }()
+	_, err := agentEvents("primary", at(0, 0), at(23, 0))
+	assert.ErrorIs(t, err, errNoAgent)
+
+	runningAgent = true
+	standIn := &syncStandIn{full: &calendar.Events{Items: existingMeetings([2]time.Time{at(9, 0), at(10, 0)}), NextSyncToken: "t1"}}
+	listener, err := listenControl()
+	assert.NoError(t, err)
+	server := &http.Server{Handler: agentHandler(syncStandInService(t, standIn))}
+	go server.Serve(listener)
+	defer server.Close()
+
+	_, err = listenControl()
+	assert.Error(t, err, "a single agent runs")
+	events, err := agentEvents("primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meeting 1"}, summaries(events))
+
+	// the reads are served from the cache the agent synced
+	added := existingMeetings([2]time.Time{at(9, 0), at(10, 0)}, [2]time.Time{at(11, 0), at(12, 0)})[1]
+	standIn.mu.Lock()
+	standIn.changes = map[string]*calendar.Events{"t1": {Items: []*calendar.Event{added}, NextSyncToken: "t2"}}
+	standIn.mu.Unlock()
+	events, err = agentEvents("primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meeting 1"}, summaries(events))
+	assert.Equal(t, []string{""}, standIn.requests)
+
+	// a change made by another command is read once the agent is told
+	runningAgent = false
+	changed("primary")
+	tellAgent()
+	assert.Empty(t, changedCalendars)
+	// unless the client is offline
+	offline = true
+	events, err = agentEvents("primary", at(0, 0), at(23, 0))
+	offline = false
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meeting 1"}, summaries(events))
+	events, err = agentEvents("primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meeting 1", "meeting 2"}, summaries(events))
+	assert.Equal(t, []string{"", "t1"}, standIn.requests)
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// planFileVersion is bumped on incompatible changes to the plan file
+const planFileVersion = 1
+
+// planFile is a plan which is saved for committing later on
+type planFile struct {
+	Version    int               `json:"version"`
+	CalendarId string            `json:"calendarId"`
+	Date       time.Time         `json:"date"`
+	EndDate    time.Time         `json:"endDate,omitempty"`
+	Events     []*calendar.Event `json:"events"`
+	// Replaced are the events calgo planned before, which are deleted once
+	// the events are committed
+	Replaced []*calendar.Event `json:"replaced,omitempty"`
+}
+
+func (p *Plan) file() planFile {
+	return planFile{
+		Version:    planFileVersion,
+		CalendarId: p.calendarId,
+		Date:       p.date,
+		EndDate:    p.endDate,
+		Events:     p.getAddedEvents(),
+		Replaced:   p.replaced,
+	}
+}
+
+func readPlanFile(path string) (*planFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pf := &planFile{}
+	if err := json.Unmarshal(b, pf); err != nil {
+		return nil, fmt.Errorf("failed parsing plan file %s: %w", path, err)
+	}
+	if pf.Version != planFileVersion {
+		return nil, fmt.Errorf("unsupported plan file version %d, expected %d", pf.Version, planFileVersion)
+	}
+	return pf, nil
+}
+
+// planFromFile creates a plan with the events of a plan file as new events
+func planFromFile(pf *planFile, service *calendar.Service) *Plan {
+	for _, e := range pf.Events {
+		// only events that were not committed can be in a plan file
+		e.Id = ""
+	}
+	p := eventsPlan(pf.CalendarId, service, pf.Events)
+	p.date = pf.Date
+	p.endDate = pf.EndDate
+	p.replaced = pf.Replaced
+	return p
+}
+
+// eventsPlan creates a plan for committing the given new events
+func eventsPlan(calendarId string, service *calendar.Service, added []*calendar.Event) *Plan {
+	events := newEvents()
+	events.addAll(added)
+	return &Plan{
+		calendarId: calendarId,
+		now:        clock,
+		eventInserter: withBusyFallback(func(event *calendar.Event) (*calendar.Event, error) {
+			return insertEvent(service, calendarId, event)
+		}),
+		eventDeleter: func(id string) error {
+			changed(calendarId)
+			return service.Events.Delete(calendarId, id).SendUpdates("all").Do()
+		},
+		workers: commitWorkers,
+		rate:    commitRate,
+		events:  events,
+	}
+}
+
+// applyCmd commits a plan that was saved with plan --output json
+var applyCmd = &cobra.Command{
+	Use:     "apply PLAN_FILE",
+	Short:   "Commit a saved plan to the calendar",
+	Example: "$ calgo plan m-f --focus-time 10h --output json > plan.json\n$ calgo apply plan.json",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pf, err := readPlanFile(args[0])
+		if err != nil {
+			return err
+		}
+		srv := google_calendar.Service()
+		plan := planFromFile(pf, srv)
+		log.Println(plan)
+		results, err := plan.commit()
+		fmt.Print(results)
+		return err
+	},
+}
+
+func init() {
+	applyCmd.Flags().BoolVar(&interactive, "interactive", true, "Ask before committing changes")
+	addCommitFlags(applyCmd)
+	rootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestPlanFileRoundTrip(t *testing.T) {
+	events := newEvents()
+	events.addAll(existingMeetings([2]time.Time{at(8, 0), at(9, 0)}))
+	p := &Plan{
+		calendarId:       "primary",
+		date:             at(0, 0),
+		overallFocusTime: time.Hour,
+		focusDuration:    time.Hour,
+		events:           events,
+	}
+	assert.NoError(t, p.plan())
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, printJSON(f, p.file()))
+	assert.NoError(t, f.Close())
+
+	pf, err := readPlanFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "primary", pf.CalendarId)
+	assert.Len(t, pf.Events, 1)
+
+	applied := planFromFile(pf, &calendar.Service{})
+	added := applied.getAddedEvents()
+	assert.Len(t, added, 1)
+	assert.Equal(t, newFocusEvent(at(9, 0), time.Hour).Start, added[0].Start)
+	assert.Equal(t, newFocusEvent(at(9, 0), time.Hour).End, added[0].End)
+}
+
+func TestPlanFileReplacedEvents(t *testing.T) {
+	nonInteractiveCommit(t)
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: time.Hour,
+		focusDuration:    time.Hour,
+		events:           newEvents(),
+		replaced:         []*calendar.Event{committedFocusEvent("old", at(8, 0), time.Hour)},
+	}
+	assert.NoError(t, p.plan())
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, printJSON(f, p.file()))
+	assert.NoError(t, f.Close())
+
+	pf, err := readPlanFile(path)
+	assert.NoError(t, err)
+	applied := planFromFile(pf, &calendar.Service{})
+	fake := &fakeCalendar{}
+	applied.eventInserter, applied.eventDeleter = fake.insert, fake.delete
+
+	_, err = applied.commit()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id1"}, fake.inserted)
+	assert.Equal(t, []string{"old"}, fake.deleted)
+}
+
+func TestReadPlanFileVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "events": []}`), 0600))
+
+	_, err := readPlanFile(path)
+	assert.Error(t, err)
+}
+
+func TestDiffString(t *testing.T) {
+	events := newEvents()
+	events.addAll(existingMeetings([2]time.Time{at(8, 0), at(9, 0)}))
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: time.Hour,
+		focusDuration:    time.Hour,
+		events:           events,
+	}
+	assert.NoError(t, p.plan())
+
+	diff := p.diffString()
+	assert.Contains(t, diff, "Mon 25 Sep")
+	assert.Contains(t, diff, "meeting 1")
+	assert.Contains(t, diff, "[+] Focus Time")
+}
@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	// offline serves the reads from the cache without syncing it
+	offline bool
+	// refresh drops the cached events and syncs them again
+	refresh bool
+)
+
+// cacheDaysBack are the past days kept in the cache, reads of earlier days
+// go to the calendar API
+const cacheDaysBack = 90
+
+// cacheOpenTimeout is how long to wait for another calgo holding the cache
+const cacheOpenTimeout = time.Second
+
+var (
+	syncTokenKey = []byte("syncToken")
+	sinceKey     = []byte("since")
+	eventsBucket = []byte("events")
+)
+
+// eventCache keeps the events of each calendar in a bucket, along with the
+// sync token to fetch the changes since the last sync
+type eventCache struct {
+	db *bolt.DB
+	mu sync.Mutex
+	// synced are the calendars synced by this process already
+	synced map[string]bool
+}
+
+var (
+	cacheMu     sync.Mutex
+	eventsCache *eventCache
+)
+
+// cachePath is a cache file per account, the account is set in the config
+// when working with several
+func cachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	account := viper.GetString("account")
+	if account == "" {
+		account = "default"
+	}
+	return filepath.Join(dir, "calgo", account+".db"), nil
+}
+
+func openCache(path string) (*eventCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: cacheOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed opening the cache %s: %w", path, err)
+	}
+	return &eventCache{db: db, synced: map[string]bool{}}, nil
+}
+
+// getCache opens the cache of the account once
+func getCache() (*eventCache, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if eventsCache != nil {
+		return eventsCache, nil
+	}
+	path, err := cachePath()
+	if err != nil {
+		return nil, err
+	}
+	eventsCache, err = openCache(path)
+	return eventsCache, err
+}
+
+func (c *eventCache) Close() error {
+	return c.db.Close()
+}
+
+// state returns the sync token of the calendar and the first day cached
+func (c *eventCache) state(calendarId string) (string, time.Time) {
+	var token string
+	var since time.Time
+	_ = c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(calendarId))
+		if b == nil {
+			return nil
+		}
+		token = string(b.Get(syncTokenKey))
+		since, _ = time.Parse(time.RFC3339, string(b.Get(sinceKey)))
+		return nil
+	})
+	return token, since
+}
+
+func isGone(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusGone
+}
+
+// sync brings the cached events of the calendar up to date, once per
+// process. Without a sync token, when the token expired or when full, all
+// the events since cacheDaysBack are fetched again.
+func (c *eventCache) sync(service *calendar.Service, calendarId string, full bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.synced[calendarId] && !full {
+		return nil
+	}
+	token, _ := c.state(calendarId)
+	if full {
+		token = ""
+	}
+	err := c.pull(service, calendarId, token)
+	if token != "" && isGone(err) {
+		log.Printf("sync token of %s expired, syncing all the events\n", calendarId)
+		err = c.pull(service, calendarId, "")
+	}
+	if err == nil {
+		c.synced[calendarId] = true
+	}
+	return err
+}
+
+// pull fetches the changes since the sync token, or all the events when
+// the token is empty, and stores them with the next sync token
+func (c *eventCache) pull(service *calendar.Service, calendarId, token string) error {
+	since := midnight(clock()).AddDate(0, 0, -cacheDaysBack)
+	call := service.Events.List(calendarId).SingleEvents(true)
+	if token == "" {
+		call = call.TimeMin(since.Format(time.RFC3339))
+	} else {
+		call = call.SyncToken(token)
+	}
+	var changed []*calendar.Event
+	var nextToken string
+	err := withRetry(func() error {
+		changed, nextToken = nil, ""
+		return call.Pages(context.Background(), func(page *calendar.Events) error {
+			changed = append(changed, page.Items...)
+			nextToken = page.NextSyncToken
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if token == "" {
+			if err := tx.DeleteBucket([]byte(calendarId)); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		b, err := tx.CreateBucketIfNotExists([]byte(calendarId))
+		if err != nil {
+			return err
+		}
+		events, err := b.CreateBucketIfNotExists(eventsBucket)
+		if err != nil {
+			return err
+		}
+		for _, e := range changed {
+			if e.Status == "cancelled" {
+				if err := events.Delete([]byte(e.Id)); err != nil {
+					return err
+				}
+				continue
+			}
+			v, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if err := events.Put([]byte(e.Id), v); err != nil {
+				return err
+			}
+		}
+		if token == "" {
+			if err := b.Put(sinceKey, []byte(since.Format(time.RFC3339))); err != nil {
+				return err
+			}
+		}
+		return b.Put(syncTokenKey, []byte(nextToken))
+	})
+}
+
+// events returns the cached events of the calendar overlapping tmin to tmax,
+// sorted by their start, and the first day the cache has events of. A zero
+// day means the calendar was never synced.
+func (c *eventCache) events(calendarId string, tmin, tmax time.Time) ([]*calendar.Event, time.Time, error) {
+	_, since := c.state(calendarId)
+	var events []*calendar.Event
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(calendarId))
+		if b == nil || b.Bucket(eventsBucket) == nil {
+			return nil
+		}
+		return b.Bucket(eventsBucket).ForEach(func(k, v []byte) error {
+			e := &calendar.Event{}
+			if err := json.Unmarshal(v, e); err != nil {
+				return err
+			}
+			start, end, err := eventTimes(e)
+			if err != nil {
+				return nil
+			}
+			if start.Before(tmax) && end.After(tmin) {
+				events = append(events, e)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(events, func(i, j int) bool {
+		si, _ := parseEventDateTime(events[i].Start)
+		sj, _ := parseEventDateTime(events[j].Start)
+		return si.Before(sj)
+	})
+	return events, since, err
+}
+
+// apiEvents lists the events of the calendar from tmin to tmax with the
+// calendar API. The query is searched by the API, if set.
+func apiEvents(service *calendar.Service, calendarId string, tmin, tmax time.Time, query string) ([]*calendar.Event, error) {
+	call := service.Events.List(calendarId).
+		ShowDeleted(showDeleted).
+		SingleEvents(true).
+		TimeMin(tmin.Format(time.RFC3339)).
+		TimeMax(tmax.Format(time.RFC3339)).
+		OrderBy(sortField)
+	if query != "" {
+		call = call.Q(query)
+	}
+	var events []*calendar.Event
+	err := withRetry(func() error {
+		events = nil
+		return call.Pages(context.Background(), func(page *calendar.Events) error {
+			events = append(events, page.Items...)
+			return nil
+		})
+	})
+	return events, err
+}
+
+// cachedEvents lists the events of the calendar from tmin to tmax from the
+// agent if it runs, or from the cache after syncing it unless --offline.
+// Ranges the cache does not have are read from the API.
+func cachedEvents(service *calendar.Service, calendarId string, tmin, tmax time.Time) ([]*calendar.Event, error) {
+	if !runningAgent {
+		events, err := agentEvents(calendarId, tmin, tmax)
+		if err == nil {
+			return events, nil
+		}
+		if !errors.Is(err, errNoAgent) {
+			log.Printf("not using the agent: %v\n", err)
+		}
+	}
+	return readCache(service, calendarId, tmin, tmax, offline, refresh)
+}
+
+// readCache lists the events from the cache of this process, syncing it
+// first unless offline, or all over again when full
+func readCache(service *calendar.Service, calendarId string, tmin, tmax time.Time, offline, full bool) ([]*calendar.Event, error) {
+	c, err := getCache()
+	if err != nil {
+		if offline {
+			return nil, fmt.Errorf("unable to work offline: %w", err)
+		}
+		log.Printf("not using the cache: %v\n", err)
+		return apiEvents(service, calendarId, tmin, tmax, "")
+	}
+	if !offline {
+		if err := c.sync(service, calendarId, full); err != nil {
+			log.Printf("failed syncing %s, using the cached events: %v\n", calendarId, err)
+		}
+	}
+	events, since, err := c.events(calendarId, tmin, tmax)
+	if err != nil {
+		return nil, err
+	}
+	if !offline && (since.IsZero() || tmin.Before(since)) {
+		return apiEvents(service, calendarId, tmin, tmax, "")
+	}
+	return events, nil
+}
+
+// matchesQuery searches the text like the calendar API does, for searching
+// offline
+func matchesQuery(e *calendar.Event, query string) bool {
+	fields := []string{e.Summary, e.Description, e.Location}
+	for _, a := range e.Attendees {
+		fields = append(fields, a.Email, a.DisplayName)
+	}
+	text := strings.ToLower(strings.Join(fields, "\n"))
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// isolatedCache points the cache of the test to a temporary directory
+func isolatedCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	closeCache := func() {
+		cacheMu.Lock()
+		defer cacheMu.Unlock()
+		if eventsCache != nil {
+			_ = eventsCache.Close()
+			eventsCache = nil
+		}
+	}
+	closeCache()
+	t.Cleanup(closeCache)
+}
+
+// syncStandIn serves the changes of each sync token, and 410 for an
+// expired one
+type syncStandIn struct {
+	mu       sync.Mutex
+	full     *calendar.Events
+	changes  map[string]*calendar.Events
+	requests []string
+}
+
+func (s *syncStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("syncToken")
+	s.mu.Lock()
+	s.requests = append(s.requests, token)
+	s.mu.Unlock()
+	if token == "" {
+		_ = json.NewEncoder(w).Encode(s.full)
+		return
+	}
+	changes, ok := s.changes[token]
+	if !ok {
+		http.Error(w, `{"error": {"code": 410, "message": "sync token expired"}}`, http.StatusGone)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(changes)
+}
+
+func syncStandInService(t *testing.T, standIn *syncStandIn) *calendar.Service {
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	srv, err := calendar.NewService(context.Background(),
+		option.WithEndpoint(server.URL+"/"),
+		option.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+	return srv
+}
+
+func summaries(events []*calendar.Event) []string {
+	var s []string
+	for _, e := range events {
+		s = append(s, e.Summary)
+	}
+	return s
+}
+
+func TestCacheSync(t *testing.T) {
+	isolatedCache(t)
+	defer func(c func() time.Time) { clock = c }(clock)
+	clock = fixedClock(at(7, 0))
+	meetings := existingMeetings(
+		[2]time.Time{at(10, 0), at(11, 0)},
+		[2]time.Time{at(9, 0), at(10, 0)},
+		[2]time.Time{at(12, 0), at(13, 0)},
+	)
+	cancelled := &calendar.Event{Id: meetings[0].Id, Status: "cancelled"}
+	standIn := &syncStandIn{
+		full: &calendar.Events{Items: meetings[:2], NextSyncToken: "t1"},
+		changes: map[string]*calendar.Events{
+			"t1": {Items: []*calendar.Event{cancelled, meetings[2]}, NextSyncToken: "t2"},
+		},
+	}
+	service := syncStandInService(t, standIn)
+
+	c, err := getCache()
+	assert.NoError(t, err)
+	// a full sync, then the changes since
+	assert.NoError(t, c.sync(service, "primary", false))
+	events, err := cachedEvents(service, "primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meeting 2", "meeting 1"}, summaries(events))
+
+	c.synced = map[string]bool{}
+	events, err = cachedEvents(service, "primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meeting 2", "meeting 3"}, summaries(events))
+
+	// the t2 token expired, all the events are synced again
+	c.synced = map[string]bool{}
+	assert.NoError(t, c.sync(service, "primary", false))
+	events, _, err = c.events("primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meeting 2", "meeting 1"}, summaries(events))
+	assert.Equal(t, []string{"", "t1", "t2", ""}, standIn.requests)
+}
+
+func TestCacheOffline(t *testing.T) {
+	isolatedCache(t)
+	defer func(c func() time.Time) { clock = c }(clock)
+	clock = fixedClock(at(7, 0))
+	standIn := &syncStandIn{full: &calendar.Events{Items: existingMeetings([2]time.Time{at(9, 0), at(10, 0)}), NextSyncToken: "t1"}}
+	service := syncStandInService(t, standIn)
+	_, err := cachedEvents(service, "primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+
+	offline = true
+	defer func() { offline = false }()
+	eventsCache.synced = map[string]bool{}
+	events, err := cachedEvents(service, "primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meeting 1"}, summaries(events))
+	assert.Len(t, standIn.requests, 1, "offline reads do not sync")
+}
+
+func TestMatchesQuery(t *testing.T) {
+	e := &calendar.Event{
+		Summary:   "Design review",
+		Attendees: []*calendar.EventAttendee{{Email: "dana@example.com"}},
+	}
+	assert.True(t, matchesQuery(e, ""))
+	assert.True(t, matchesQuery(e, "review dana"))
+	assert.False(t, matchesQuery(e, "review joe"))
+}
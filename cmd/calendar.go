@@ -4,6 +4,8 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	pretty "github.com/jedib0t/go-pretty/v6/text"
 	"github.com/rgolangh/calgo/internal/google_calendar"
@@ -11,10 +13,24 @@ import (
 	"google.golang.org/api/calendar/v3"
 )
 
+var (
+	calendarOutput string
+	shareRole      string
+)
+
+// aclRoles maps the --role values to the calendar API roles
+var aclRoles = map[string]string{
+	"reader": "reader",
+	"writer": "writer",
+}
+
 // calendarCmd represents the calendar command
 var calendarCmd = &cobra.Command{
 	Use:   "calendar",
 	Short: "List all user's calendars",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutputFormat(calendarOutput)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		srv := google_calendar.Service()
 
@@ -22,6 +38,10 @@ var calendarCmd = &cobra.Command{
 		if err != nil {
 			log.Fatalf("unable to retrieve calendars")
 		}
+		if calendarOutput == outputJSON {
+			cobra.CheckErr(printJSON(os.Stdout, calendars.Items))
+			return
+		}
 		if len(calendars.Items) == 0 {
 			fmt.Printf("No calendars")
 			return
@@ -31,7 +51,184 @@ var calendarCmd = &cobra.Command{
 	},
 }
 
+var calendarCreateCmd = &cobra.Command{
+	Use:     "create NAME",
+	Short:   "Create a calendar",
+	Example: `$ calgo calendar create "team on call"`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := google_calendar.Service()
+		// the time zone of a calendar is optional, the account's is used
+		// when it is unknown
+		timeZone, _ := localTimeZone()
+		created, err := srv.Calendars.Insert(&calendar.Calendar{Summary: args[0], TimeZone: timeZone}).Do()
+		if err != nil {
+			return fmt.Errorf("unable to create calendar: %w", err)
+		}
+		return printCalendarResult(created.Id, created)
+	},
+}
+
+// newAclRule shares a calendar with a user
+func newAclRule(email, role string) *calendar.AclRule {
+	return &calendar.AclRule{
+		Role:  aclRoles[role],
+		Scope: &calendar.AclRuleScope{Type: "user", Value: email},
+	}
+}
+
+var calendarShareCmd = &cobra.Command{
+	Use:     "share CALENDAR_ID EMAIL",
+	Short:   "Share a calendar with a user",
+	Example: "$ calgo calendar share team@group.calendar.google.com dana@example.com --role writer",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		if _, ok := aclRoles[shareRole]; !ok {
+			return fmt.Errorf("--role must be one of %s", keys(aclRoles))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := google_calendar.Service()
+		rule, err := srv.Acl.Insert(args[0], newAclRule(args[1], shareRole)).Do()
+		if err != nil {
+			return fmt.Errorf("unable to share calendar: %w", err)
+		}
+		return printCalendarResult(fmt.Sprintf("shared with %s as %s", rule.Scope.Value, rule.Role), rule)
+	},
+}
+
+var calendarSubscribeCmd = &cobra.Command{
+	Use:     "subscribe CALENDAR_ID",
+	Short:   "Add a calendar shared with you to your calendar list",
+	Example: "$ calgo calendar subscribe en.usa#holiday@group.v.calendar.google.com",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := google_calendar.Service()
+		entry, err := srv.CalendarList.Insert(&calendar.CalendarListEntry{Id: args[0]}).Do()
+		if err != nil {
+			return fmt.Errorf("unable to subscribe to calendar: %w", err)
+		}
+		return printCalendarResult("subscribed to "+entry.Summary, entry)
+	},
+}
+
+// selectedPatch shows or hides a calendar of the calendar list
+func selectedPatch(selected bool) *calendar.CalendarListEntry {
+	return &calendar.CalendarListEntry{Selected: selected, ForceSendFields: []string{"Selected"}}
+}
+
+func newSelectCalendarCmd(use, short string, selected bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " CALENDAR_ID",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srv := google_calendar.Service()
+			entry, err := srv.CalendarList.Patch(args[0], selectedPatch(selected)).Do()
+			if err != nil {
+				return fmt.Errorf("unable to %s calendar: %w", use, err)
+			}
+			return printCalendarResult(fmt.Sprintf("%s selected: %v", entry.Summary, entry.Selected), entry)
+		},
+	}
+}
+
+// calendarInfo are the settings of a calendar and who it is shared with
+type calendarInfo struct {
+	Id               string                    `json:"id"`
+	Summary          string                    `json:"summary"`
+	Description      string                    `json:"description,omitempty"`
+	TimeZone         string                    `json:"timeZone"`
+	AccessRole       string                    `json:"accessRole"`
+	DefaultReminders []*calendar.EventReminder `json:"defaultReminders"`
+	// Acl is only readable by the owners of the calendar
+	Acl []*calendar.AclRule `json:"acl,omitempty"`
+}
+
+func (c *calendarInfo) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", pretty.Bold.Sprint(c.Summary))
+	fmt.Fprintf(&b, "%-18s%s\n", "id", c.Id)
+	if c.Description != "" {
+		fmt.Fprintf(&b, "%-18s%s\n", "description", c.Description)
+	}
+	fmt.Fprintf(&b, "%-18s%s\n", "time zone", c.TimeZone)
+	fmt.Fprintf(&b, "%-18s%s\n", "access", c.AccessRole)
+	var reminders []string
+	for _, r := range c.DefaultReminders {
+		reminders = append(reminders, fmt.Sprintf("%s %dm before", r.Method, r.Minutes))
+	}
+	if len(reminders) == 0 {
+		reminders = []string{"none"}
+	}
+	fmt.Fprintf(&b, "%-18s%s\n", "default reminders", strings.Join(reminders, ", "))
+	if c.Acl != nil {
+		fmt.Fprintf(&b, "%-18s\n", "shared with")
+		for _, rule := range c.Acl {
+			fmt.Fprintf(&b, "  %-30s%s\n", rule.Scope.Type+":"+rule.Scope.Value, rule.Role)
+		}
+	}
+	return b.String()
+}
+
+var calendarInfoCmd = &cobra.Command{
+	Use:     "info CALENDAR_ID",
+	Short:   "Show the settings of a calendar and who it is shared with",
+	Example: "$ calgo calendar info primary",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := google_calendar.Service()
+		entry, err := srv.CalendarList.Get(args[0]).Do()
+		if err != nil {
+			return fmt.Errorf("unable to retrieve calendar: %w", err)
+		}
+		info := &calendarInfo{
+			Id:               entry.Id,
+			Summary:          entry.Summary,
+			Description:      entry.Description,
+			TimeZone:         entry.TimeZone,
+			AccessRole:       entry.AccessRole,
+			DefaultReminders: entry.DefaultReminders,
+		}
+		if entry.AccessRole == "owner" {
+			acl, err := srv.Acl.List(args[0]).Do()
+			if err != nil {
+				return fmt.Errorf("unable to retrieve the sharing of calendar: %w", err)
+			}
+			info.Acl = acl.Items
+		}
+		if calendarOutput == outputJSON {
+			return printJSON(os.Stdout, info)
+		}
+		fmt.Print(info)
+		return nil
+	},
+}
+
+// printCalendarResult prints the message, or the resource with --output json
+func printCalendarResult(message string, resource interface{}) error {
+	if calendarOutput == outputJSON {
+		return printJSON(os.Stdout, resource)
+	}
+	fmt.Println(message)
+	return nil
+}
+
 func init() {
+	// the output format is of the subcommands as well
+	calendarCmd.PersistentFlags().StringVarP(&calendarOutput, "output", "o", outputText, fmt.Sprintf("output format, either %s or %s", outputText, outputJSON))
+	calendarShareCmd.Flags().StringVar(&shareRole, "role", "reader", fmt.Sprintf("access to the calendar, one of %s", keys(aclRoles)))
+	calendarCmd.AddCommand(
+		calendarCreateCmd,
+		calendarShareCmd,
+		calendarSubscribeCmd,
+		newSelectCalendarCmd("hide", "Hide a calendar in the calendar list, and from calgo list --all-calendars", false),
+		newSelectCalendarCmd("show", "Show a calendar in the calendar list, and in calgo list --all-calendars", true),
+		calendarInfoCmd,
+	)
 	rootCmd.AddCommand(calendarCmd)
 }
 
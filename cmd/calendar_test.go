@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestNewAclRule(t *testing.T) {
+	rule := newAclRule("dana@example.com", "writer")
+	assert.Equal(t, "writer", rule.Role)
+	assert.Equal(t, &calendar.AclRuleScope{Type: "user", Value: "dana@example.com"}, rule.Scope)
+}
+
+func TestSelectedPatchSendsFalse(t *testing.T) {
+	b, err := json.Marshal(selectedPatch(false))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"selected": false}`, string(b))
+}
+
+func TestCalendarInfoString(t *testing.T) {
+	info := &calendarInfo{
+		Id:               "team@group.calendar.google.com",
+		Summary:          "team",
+		TimeZone:         "Asia/Jerusalem",
+		AccessRole:       "owner",
+		DefaultReminders: []*calendar.EventReminder{{Method: "popup", Minutes: 10}},
+		Acl: []*calendar.AclRule{
+			{Role: "writer", Scope: &calendar.AclRuleScope{Type: "user", Value: "dana@example.com"}},
+		},
+	}
+	s := info.String()
+	assert.Contains(t, s, "Asia/Jerusalem")
+	assert.Contains(t, s, "popup 10m before")
+	assert.Contains(t, s, "user:dana@example.com")
+
+	info.DefaultReminders = nil
+	info.Acl = nil
+	assert.Contains(t, info.String(), "default reminders none")
+	assert.NotContains(t, info.String(), "shared with")
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"time"
+)
+
+// clock returns the current time. Commands read the time through it and not
+// directly from time.Now, so it can be pinned by tests and by the hidden
+// --now flag, when reproducing what a user saw.
+var clock = time.Now
+
+var nowFlag string
+
+// nowLayouts are the accepted forms of the --now flag
+var nowLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04"}
+
+func fixedClock(t time.Time) func() time.Time {
+	return func() time.Time {
+		return t
+	}
+}
+
+// initClock pins the clock if --now is set
+func initClock() error {
+	if nowFlag == "" {
+		return nil
+	}
+	for _, layout := range nowLayouts {
+		if t, err := time.ParseInLocation(layout, nowFlag, time.Local); err == nil {
+			clock = fixedClock(t)
+			return nil
+		}
+	}
+	return fmt.Errorf("--now %q is not in the form of %s", nowFlag, nowLayouts[0])
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitClock(t *testing.T) {
+	defer func(c func() time.Time, n string) { clock, nowFlag = c, n }(clock, nowFlag)
+
+	nowFlag = "2022-08-30T19:50"
+	assert.NoError(t, initClock())
+	assert.Equal(t, time.Date(2022, 8, 30, 19, 50, 0, 0, time.Local), clock())
+
+	nowFlag = "yesterday"
+	assert.Error(t, initClock())
+}
+
+func TestTimeBoundariesWithClock(t *testing.T) {
+	defer func(c func() time.Time) { clock = c }(clock)
+	tuesday := time.Date(2022, 8, 30, 19, 50, 0, 0, time.UTC)
+	clock = fixedClock(tuesday)
+
+	tmin, tmax, err := getTimeBoundaries(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 8, 30, 8, 0, 0, 0, time.UTC), tmin)
+	assert.Equal(t, time.Date(2022, 8, 30, 20, 0, 0, 0, time.UTC), tmax)
+
+	tmin, tmax, err = getTimeBoundaries([]string{"th-f"})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 9, 1, 8, 0, 0, 0, time.UTC), tmin)
+	assert.Equal(t, time.Date(2022, 9, 2, 20, 0, 0, 0, time.UTC), tmax)
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+type commitStatus string
+
+const (
+	statusCreated    commitStatus = "created"
+	statusFailed     commitStatus = "failed"
+	statusSkipped    commitStatus = "skipped"
+	statusRolledBack commitStatus = "rolled back"
+	statusDeleted    commitStatus = "deleted"
+)
+
+// commitResult is the outcome of committing a single event of a plan
+type commitResult struct {
+	event  *calendar.Event
+	status commitStatus
+	err    error
+}
+
+type commitResults []*commitResult
+
+func (r commitResults) String() string {
+	buf := bytes.NewBufferString("")
+	for _, result := range r {
+		start, _, _ := eventTimes(result.event)
+		line := fmt.Sprintf("%-11s %s %s", result.status, start.Format(dayFormat), eventString(result.event))
+		if result.err != nil {
+			line = fmt.Sprintf("%s%12s%v\n", line, "", result.err)
+		}
+		fmt.Fprint(buf, line)
+	}
+	return buf.String()
+}
+
+// summary counts the results by their status, e.g for a status line
+func (r commitResults) summary() string {
+	counts := map[commitStatus]int{}
+	for _, result := range r {
+		counts[result.status]++
+	}
+	var parts []string
+	for _, status := range []commitStatus{statusCreated, statusDeleted, statusFailed, statusRolledBack, statusSkipped} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(parts) == 0 {
+		return "nothing committed"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// commit inserts the added events of the plan to the calendar. It is all or
+// nothing, if an event fails to be created then the events that were already
+// created are rolled back, after asking when interactive. The results tell
+// what happened to each of the events, for the caller to show.
+func (p *Plan) commit() (commitResults, error) {
+	if p.asks() {
+		commit, err := confirm("Commit changes to the calendar?", true)
+		if err != nil || !commit {
+			return nil, err
+		}
+	}
+
+	if p.id == "" {
+		p.id = newPlanId(p.currentTime())
+	}
+	added := p.getAddedEvents()
+	results := make(commitResults, len(added))
+	for i, newEvent := range added {
+		stampPlan(newEvent, p.id)
+		results[i] = &commitResult{event: newEvent, status: statusSkipped}
+	}
+	// once an event fails the events which didn't start are skipped
+	var failed int32
+	runPool(len(added), p.workers, p.rate, func(i int, wait func()) {
+		result := results[i]
+		if atomic.LoadInt32(&failed) == 1 {
+			return
+		}
+		created, err := insertWithRetry(result.event, func(event *calendar.Event) (*calendar.Event, error) {
+			// every attempt counts in the rate, the retries of rate
+			// limited inserts too
+			wait()
+			return p.eventInserter(event)
+		})
+		result.err = err
+		if result.err != nil {
+			result.status = statusFailed
+			atomic.StoreInt32(&failed, 1)
+			return
+		}
+		result.status = statusCreated
+		// the event is persisted now, and it is not an added event anymore
+		result.event.Id = created.Id
+	})
+
+	var failure error
+	for _, result := range results {
+		if result.status == statusFailed {
+			failure = fmt.Errorf("unable to create event %q: %w", result.event.Summary, result.err)
+			break
+		}
+	}
+	if failure != nil {
+		if err := p.rollback(results); err != nil {
+			log.Printf("failed rolling back: %v\n", err)
+		}
+	} else {
+		failure = p.deleteReplaced(&results)
+	}
+	return results, failure
+}
+
+// deleteReplaced deletes the events that the committed plan replaces
+func (p *Plan) deleteReplaced(results *commitResults) error {
+	for _, e := range p.replaced {
+		result := &commitResult{event: e, status: statusDeleted}
+		*results = append(*results, result)
+		result.err = withRetry(func() error {
+			return p.eventDeleter(e.Id)
+		})
+		if result.err != nil {
+			result.status = statusFailed
+			return fmt.Errorf("unable to delete replaced event %q: %w", e.Summary, result.err)
+		}
+	}
+	p.replaced = nil
+	return nil
+}
+
+// asks tells if commit and rollback ask before changing the calendar
+func (p *Plan) asks() bool {
+	return interactive && !p.confirmed
+}
+
+// rollback deletes the events created by a failed commit
+func (p *Plan) rollback(results commitResults) error {
+	var created commitResults
+	for _, result := range results {
+		if result.status == statusCreated {
+			created = append(created, result)
+		}
+	}
+	if len(created) == 0 {
+		return nil
+	}
+	if p.asks() {
+		rollback, err := confirm(fmt.Sprintf("Roll back the %d events that were already created?", len(created)), true)
+		if err != nil {
+			return err
+		}
+		if !rollback {
+			return nil
+		}
+	}
+	for _, result := range created {
+		err := withRetry(func() error {
+			return p.eventDeleter(result.event.Id)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to delete event %q: %w", result.event.Summary, err)
+		}
+		result.event.Id = ""
+		result.status = statusRolledBack
+	}
+	return nil
+}
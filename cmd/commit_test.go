@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// fakeCalendar stands in for the calendar API when committing plans
+type fakeCalendar struct {
+	inserted []string
+	deleted  []string
+	// failures maps a 1 based insert call number to the error it fails with
+	failures map[int]error
+	calls    int
+}
+
+func (f *fakeCalendar) insert(event *calendar.Event) (*calendar.Event, error) {
+	f.calls++
+	if err, ok := f.failures[f.calls]; ok {
+		return nil, err
+	}
+	id := fmt.Sprintf("id%d", f.calls)
+	f.inserted = append(f.inserted, id)
+	return &calendar.Event{Id: id}, nil
+}
+
+func (f *fakeCalendar) delete(id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func planWithFocusEvents(f *fakeCalendar, n int) *Plan {
+	events := newEvents()
+	for i := 0; i < n; i++ {
+		events.insert(newFocusEvent(at(8+i, 0), time.Hour))
+	}
+	return &Plan{
+		date:          at(0, 0),
+		eventInserter: f.insert,
+		eventDeleter:  f.delete,
+		events:        events,
+	}
+}
+
+// nonInteractiveCommit commits without prompting and without waiting between retries
+func nonInteractiveCommit(t *testing.T) {
+	s, i := sleep, interactive
+	t.Cleanup(func() { sleep, interactive = s, i })
+	sleep = func(time.Duration) {}
+	interactive = false
+}
+
+func TestCommit(t *testing.T) {
+	nonInteractiveCommit(t)
+	f := &fakeCalendar{}
+	p := planWithFocusEvents(f, 3)
+
+	results, err := p.commit()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id1", "id2", "id3"}, f.inserted)
+	assert.Empty(t, p.getAddedEvents())
+	assert.Equal(t, "3 created", results.summary())
+}
+
+func TestCommitRollsBackOnFailure(t *testing.T) {
+	nonInteractiveCommit(t)
+	f := &fakeCalendar{failures: map[int]error{3: &googleapi.Error{Code: http.StatusBadRequest}}}
+	p := planWithFocusEvents(f, 5)
+
+	results, err := p.commit()
+	assert.Error(t, err)
+	assert.Equal(t, 3, f.calls, "inserts after the failure are skipped")
+	assert.Equal(t, []string{"id1", "id2"}, f.deleted)
+	assert.Len(t, p.getAddedEvents(), 5, "rolled back events are not committed")
+	assert.Equal(t, "1 failed, 2 rolled back, 2 skipped", results.summary())
+}
+
+func TestCommitRetries(t *testing.T) {
+	nonInteractiveCommit(t)
+	f := &fakeCalendar{failures: map[int]error{
+		1: &googleapi.Error{Code: http.StatusTooManyRequests},
+		2: &googleapi.Error{Code: http.StatusServiceUnavailable},
+	}}
+	p := planWithFocusEvents(f, 2)
+
+	_, err := p.commit()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id3", "id4"}, f.inserted)
+	assert.Empty(t, f.deleted)
+}
+
+func TestWithRetryGivesUp(t *testing.T) {
+	nonInteractiveCommit(t)
+	calls := 0
+	err := withRetry(func() error {
+		calls++
+		return &googleapi.Error{Code: http.StatusInternalServerError}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, retryAttempts, calls)
+}
+
+func TestInsertWithRetryAfterServerCreatedTheEvent(t *testing.T) {
+	nonInteractiveCommit(t)
+	var ids []string
+	created := map[string]bool{}
+	insert := func(event *calendar.Event) (*calendar.Event, error) {
+		ids = append(ids, event.Id)
+		if created[event.Id] {
+			return nil, &googleapi.Error{Code: http.StatusConflict}
+		}
+		// the insert is committed, but the response is lost
+		created[event.Id] = true
+		return nil, &googleapi.Error{Code: http.StatusBadGateway}
+	}
+	event := newFocusEvent(at(8, 0), time.Hour)
+
+	inserted, err := insertWithRetry(event, insert)
+	assert.NoError(t, err)
+	assert.Len(t, created, 1, "a retry does not create a duplicate")
+	assert.Equal(t, []string{ids[0], ids[0]}, ids, "the retry sends the same id")
+	assert.Equal(t, ids[0], inserted.Id)
+	assert.Empty(t, event.Id, "the planned event is not committed yet")
+}
+
+func TestInsertWithRetryConflictOnFirstAttempt(t *testing.T) {
+	nonInteractiveCommit(t)
+	_, err := insertWithRetry(newFocusEvent(at(8, 0), time.Hour), func(event *calendar.Event) (*calendar.Event, error) {
+		return nil, &googleapi.Error{Code: http.StatusConflict}
+	})
+	assert.Error(t, err)
+}
+
+func TestCommitConfirmedPlanDoesNotAsk(t *testing.T) {
+	nonInteractiveCommit(t)
+	interactive = true
+	a := askOne
+	t.Cleanup(func() { askOne = a })
+	askOne = func(p survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+		t.Fatalf("asked %#v", p)
+		return nil
+	}
+	f := &fakeCalendar{failures: map[int]error{2: &googleapi.Error{Code: http.StatusBadRequest}}}
+	p := planWithFocusEvents(f, 2)
+	p.confirmed = true
+
+	_, err := p.commit()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"id1"}, f.deleted, "rolled back without asking")
+	assert.True(t, interactive, "the global is left as is")
+}
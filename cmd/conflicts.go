@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	conflictsOutput string
+	travelBuffer    time.Duration
+)
+
+// maxAlternatives is the number of alternative slots suggested per conflict
+const maxAlternatives = 3
+
+const (
+	conflictOverlap    = "overlap"
+	conflictBackToBack = "back-to-back"
+	conflictOffHours   = "outside working hours"
+)
+
+// conflictEvent is the part of an event reported in a conflict
+type conflictEvent struct {
+	Id      string    `json:"id"`
+	Summary string    `json:"summary"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+}
+
+// conflict is a problem of an event in the calendar. The event is the one
+// to move, the alternatives are free slots it fits into.
+type conflict struct {
+	Kind         string         `json:"kind"`
+	Event        conflictEvent  `json:"event"`
+	With         *conflictEvent `json:"with,omitempty"`
+	Alternatives []Slot         `json:"alternatives"`
+	event        *calendar.Event
+}
+
+func newConflictEvent(e *calendar.Event) conflictEvent {
+	start, end, _ := eventTimes(e)
+	return conflictEvent{Id: e.Id, Summary: e.Summary, Start: start, End: end}
+}
+
+// isAccepted tells if the user accepted the event, events without the user
+// as an attendee are their own events
+func isAccepted(e *calendar.Event) bool {
+	for _, a := range e.Attendees {
+		if a.Self {
+			return a.ResponseStatus == "accepted"
+		}
+	}
+	return true
+}
+
+// isFocusEvent tells if the event is a focus block rather than a meeting
+func isFocusEvent(e *calendar.Event) bool {
+	return e.EventType == "focusTime" || calgoKind(e) == kindFocus
+}
+
+// findConflicts reports the accepted events which overlap, the meetings
+// which follow each other closer than the buffer and the meetings outside
+// working hours. The events are expected sorted by their start.
+func findConflicts(events []*calendar.Event, buffer time.Duration) []*conflict {
+	var accepted []*calendar.Event
+	for _, e := range events {
+		if isBusy(e) && isAccepted(e) && e.EventType != "outOfOffice" {
+			accepted = append(accepted, e)
+		}
+	}
+	var conflicts []*conflict
+	add := func(kind string, e, with *calendar.Event) {
+		c := &conflict{Kind: kind, Event: newConflictEvent(e), event: e}
+		if with != nil {
+			w := newConflictEvent(with)
+			c.With = &w
+		}
+		conflicts = append(conflicts, c)
+	}
+	for i, e := range accepted {
+		start, end, err := eventTimes(e)
+		if err != nil {
+			continue
+		}
+		if !isFocusEvent(e) && (start.Before(startOfDay(start)) || end.After(endOfDay(start))) {
+			add(conflictOffHours, e, nil)
+		}
+		for _, next := range accepted[i+1:] {
+			nextStart, _, err := eventTimes(next)
+			if err != nil {
+				continue
+			}
+			if nextStart.Before(end) {
+				add(conflictOverlap, next, e)
+				continue
+			}
+			if nextStart.Sub(end) < buffer && !isFocusEvent(e) && !isFocusEvent(next) {
+				add(conflictBackToBack, next, e)
+			}
+			break
+		}
+	}
+	return conflicts
+}
+
+// suggestAlternatives finds free slots on the day of each conflicting event
+// which fit it, using the free slots of the planner
+func suggestAlternatives(events []*calendar.Event, conflicts []*conflict) error {
+	for _, c := range conflicts {
+		others := newEvents()
+		for _, e := range events {
+			if e != c.event {
+				others.insert(e)
+			}
+		}
+		p := &Plan{events: others}
+		free, err := p.freeSlots(c.Event.Start)
+		if err != nil {
+			return err
+		}
+		duration := c.Event.End.Sub(c.Event.Start)
+		c.Alternatives = []Slot{}
+		for _, slot := range free {
+			if len(c.Alternatives) == maxAlternatives {
+				break
+			}
+			if slot.Duration() >= duration {
+				c.Alternatives = append(c.Alternatives, Slot{StartTime: slot.StartTime, EndTime: slot.StartTime.Add(duration)})
+			}
+		}
+	}
+	return nil
+}
+
+func conflictsString(conflicts []*conflict) string {
+	if len(conflicts) == 0 {
+		return "No conflicts found.\n"
+	}
+	var b strings.Builder
+	for _, c := range conflicts {
+		fmt.Fprintf(&b, "%s %s: %s", c.Event.Start.Format(dayFormat), c.Kind, eventString(c.event))
+		if c.With != nil {
+			fmt.Fprintf(&b, "%22s %s - %s %s\n", "with", c.With.Start.Format(time.Kitchen), c.With.End.Format(time.Kitchen), c.With.Summary)
+		}
+		if len(c.Alternatives) == 0 {
+			fmt.Fprintf(&b, "%22s\n", "no free slot that day")
+			continue
+		}
+		var alternatives []string
+		for _, a := range c.Alternatives {
+			alternatives = append(alternatives, a.StartTime.Format(time.Kitchen))
+		}
+		fmt.Fprintf(&b, "%22s %s\n", "try", strings.Join(alternatives, ", "))
+	}
+	return b.String()
+}
+
+// conflictsCmd reports double bookings and other scheduling problems
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts [DAY EXPRESSION]/[RANGE EXPRESSION]",
+	Short: "Report double bookings and other conflicts",
+	Long: `Report overlapping accepted events, back to back meetings with no buffer
+between them and meetings outside working hours, with free slots of the same
+day to move them to.`,
+	Example: `$ calgo conflicts m-f
+$ calgo conflicts m-f --buffer 15m --output json`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := validateDateExpressionArgs(args); err != nil {
+			return err
+		}
+		if travelBuffer < 0 {
+			return fmt.Errorf("--buffer must not be negative")
+		}
+		return validateOutputFormat(conflictsOutput)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, tmax, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		srv := google_calendar.Service()
+		items, err := cachedEvents(srv, calendarID, midnight(tmin), midnight(tmax).AddDate(0, 0, 1))
+		if err != nil {
+			return fmt.Errorf("unable to retrieve events: %w", err)
+		}
+		conflicts := findConflicts(items, travelBuffer)
+		if err := suggestAlternatives(items, conflicts); err != nil {
+			return err
+		}
+		if conflictsOutput == outputJSON {
+			if conflicts == nil {
+				conflicts = []*conflict{}
+			}
+			return printJSON(os.Stdout, conflicts)
+		}
+		fmt.Print(conflictsString(conflicts))
+		return nil
+	},
+}
+
+func init() {
+	conflictsCmd.Flags().DurationVar(&travelBuffer, "buffer", 5*time.Minute, "shortest break between meetings, closer meetings are back to back")
+	addOutputFlag(conflictsCmd, &conflictsOutput)
+	rootCmd.AddCommand(conflictsCmd)
+}
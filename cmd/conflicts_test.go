@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestFindConflicts(t *testing.T) {
+	events := existingMeetings(
+		[2]time.Time{at(7, 0), at(7, 30)},   // 1 outside working hours
+		[2]time.Time{at(9, 0), at(10, 0)},   // 2
+		[2]time.Time{at(9, 30), at(10, 30)}, // 3 overlaps 2
+		[2]time.Time{at(10, 30), at(11, 0)}, // 4 back to back with 3
+		[2]time.Time{at(11, 0), at(12, 0)},  // 5 declined, not a conflict
+		[2]time.Time{at(14, 0), at(15, 0)},  // 6
+	)
+	events[4].Attendees = []*calendar.EventAttendee{{Self: true, ResponseStatus: "declined"}}
+
+	conflicts := findConflicts(events, 5*time.Minute)
+
+	var got [][2]string
+	for _, c := range conflicts {
+		with := ""
+		if c.With != nil {
+			with = c.With.Id
+		}
+		got = append(got, [2]string{c.Kind + " " + c.Event.Id, with})
+	}
+	assert.Equal(t, [][2]string{
+		{conflictOffHours + " 1", ""},
+		{conflictOverlap + " 3", "2"},
+		{conflictBackToBack + " 4", "3"},
+	}, got)
+}
+
+func TestFindConflictsIgnoresFocusBackToBack(t *testing.T) {
+	events := existingMeetings([2]time.Time{at(9, 0), at(10, 0)})
+	focus := committedFocusEvent("focus", at(10, 0), time.Hour)
+	events = append(events, focus)
+
+	assert.Empty(t, findConflicts(events, 5*time.Minute))
+}
+
+func TestSuggestAlternatives(t *testing.T) {
+	// free: 08:00-09:00, 10:00-20:00 once the overlapping event moves
+	events := existingMeetings(
+		[2]time.Time{at(9, 0), at(10, 0)},
+		[2]time.Time{at(9, 30), at(10, 30)},
+	)
+	conflicts := findConflicts(events, 0)
+
+	err := suggestAlternatives(events, conflicts)
+	assert.NoError(t, err)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, []Slot{
+		{StartTime: at(8, 0), EndTime: at(9, 0)},
+		{StartTime: at(10, 0), EndTime: at(11, 0)},
+	}, conflicts[0].Alternatives)
+	assert.Contains(t, conflictsString(conflicts), "try 8:00AM, 10:00AM")
+}
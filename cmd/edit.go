@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	editTitle       string
+	editDescription string
+	editLocation    string
+)
+
+// editCmd updates the details of an event
+var editCmd = &cobra.Command{
+	Use:   "edit SELECTOR",
+	Short: "Edit the title, description or location of an event",
+	Long: `Edit an event. The event is selected by its index in the last list, by a
+prefix of its id, or by a part of its title.`,
+	Example: `$ calgo list
+$ calgo edit 2 --title "1:1 with dana"
+$ calgo edit standup --location "room 4" --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		patch := &calendar.Event{}
+		if cmd.Flags().Changed("title") {
+			patch.Summary = editTitle
+		}
+		if cmd.Flags().Changed("description") {
+			patch.Description = editDescription
+			patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+		}
+		if cmd.Flags().Changed("location") {
+			patch.Location = editLocation
+			patch.ForceSendFields = append(patch.ForceSendFields, "Location")
+		}
+		if patch.Summary == "" && len(patch.ForceSendFields) == 0 {
+			return fmt.Errorf("nothing to edit, use --title, --description or --location")
+		}
+
+		srv := google_calendar.Service()
+		event, calendarId, err := selectEvent(srv, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Print(eventString(event))
+		if ok, err := confirmChange("Edit this event?"); err != nil || !ok {
+			return err
+		}
+		var patched *calendar.Event
+		changed(calendarId)
+		err = withRetry(func() (err error) {
+			patched, err = srv.Events.Patch(calendarId, event.Id, patch).Do()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("unable to edit event: %w", err)
+		}
+		fmt.Print(eventString(patched))
+		return nil
+	},
+}
+
+func init() {
+	editCmd.Flags().StringVar(&editTitle, "title", "", "new title of the event")
+	editCmd.Flags().StringVar(&editDescription, "description", "", "new description of the event")
+	editCmd.Flags().StringVar(&editLocation, "location", "", "new location of the event")
+	addSelectorFlags(editCmd)
+	rootCmd.AddCommand(editCmd)
+}
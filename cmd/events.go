@@ -3,25 +3,29 @@ package cmd
 
 import (
 	"container/list"
+	"fmt"
 	"google.golang.org/api/calendar/v3"
 	"time"
 )
 
+// allDayFormat is the layout of the Date field of all-day events
+const allDayFormat = "2006-01-02"
+
 type Events struct {
 	*list.List
 }
 
-// insert and event , insertion order is by datetime
+// insert and event , insertion order is by start datetime
 func (p *Events) insert(event *calendar.Event) {
 	if p.Len() == 0 {
 		p.PushFront(event)
 		return
 	}
+	candidateStartTime, _ := parseEventDateTime(event.Start)
 	for currentElement := p.Front(); currentElement != nil; currentElement = currentElement.Next() {
-		candidateEndTime, _ := time.Parse(time.RFC3339, event.End.DateTime)
 		currentEvent := currentElement.Value.(*calendar.Event)
-		currentStartTime, _ := time.Parse(time.RFC3339, currentEvent.Start.DateTime)
-		if candidateEndTime.Before(currentStartTime) {
+		currentStartTime, _ := parseEventDateTime(currentEvent.Start)
+		if candidateStartTime.Before(currentStartTime) {
 			p.InsertBefore(event, currentElement)
 			return
 		}
@@ -35,6 +39,63 @@ func (p *Events) addAll(events []*calendar.Event) {
 	}
 }
 
+// items returns the events in their order
+func (p *Events) items() []*calendar.Event {
+	items := make([]*calendar.Event, 0, p.Len())
+	for e := p.Front(); e != nil; e = e.Next() {
+		items = append(items, e.Value.(*calendar.Event))
+	}
+	return items
+}
+
 func newEvents() Events {
 	return Events{list.New()}
 }
+
+// eventTimes returns the start and end time of an event. All-day events are
+// spanning from midnight of their start date to midnight of their end date.
+func eventTimes(e *calendar.Event) (time.Time, time.Time, error) {
+	start, err := parseEventDateTime(e.Start)
+	if err != nil {
+		return start, start, err
+	}
+	end, err := parseEventDateTime(e.End)
+	return start, end, err
+}
+
+func parseEventDateTime(dt *calendar.EventDateTime) (time.Time, error) {
+	if dt == nil {
+		return time.Time{}, fmt.Errorf("event has no date")
+	}
+	if dt.DateTime == "" {
+		return time.ParseInLocation(allDayFormat, dt.Date, time.Local)
+	}
+	return time.Parse(time.RFC3339, dt.DateTime)
+}
+
+// isBusy tells if the event blocks the time it spans. All-day events, events
+// marked as free and events the user declined do not block time. Out of
+// office always blocks time, even when it spans whole days.
+func isBusy(e *calendar.Event) bool {
+	if e.EventType == "outOfOffice" {
+		return true
+	}
+	if e.Start == nil || e.Start.DateTime == "" || e.Transparency == "transparent" {
+		return false
+	}
+	for _, a := range e.Attendees {
+		if a.Self && a.ResponseStatus == "declined" {
+			return false
+		}
+	}
+	return true
+}
+
+// nextDate returns the date after an all-day date
+func nextDate(date string) string {
+	d, err := time.Parse(allDayFormat, date)
+	if err != nil {
+		return date
+	}
+	return d.AddDate(0, 0, 1).Format(allDayFormat)
+}
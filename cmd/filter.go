@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// responseStatuses are the values of --status
+var responseStatuses = map[string]string{
+	"accepted":    "accepted",
+	"tentative":   "tentative",
+	"declined":    "declined",
+	"needsAction": "needsAction",
+}
+
+// eventTypes are the values of --type
+var eventTypes = map[string]string{
+	"default":         "default",
+	"focusTime":       "focusTime",
+	"outOfOffice":     "outOfOffice",
+	"workingLocation": "workingLocation",
+}
+
+// eventFilter selects events by the filter flags. An event must match all
+// the filters which are set, and any of the values of a repeated filter,
+// except for tags which must all be on the event.
+type eventFilter struct {
+	// query is searched by the calendar API in the event fields
+	query       string
+	attendees   []string
+	organizers  []string
+	statuses    []string
+	types       []string
+	minDuration time.Duration
+	hasMeet     bool
+	tags        []string
+}
+
+var listFilter eventFilter
+
+func addFilterFlags(cmd *cobra.Command, f *eventFilter) {
+	cmd.Flags().StringVar(&f.query, "query", "", "free text searched in the title, description, location and attendees")
+	cmd.Flags().StringSliceVar(&f.attendees, "attendee", nil, "events with an attendee whose email contains the value, repeat for any of several")
+	cmd.Flags().StringSliceVar(&f.organizers, "organizer", nil, "events with an organizer whose email contains the value, repeat for any of several")
+	cmd.Flags().StringSliceVar(&f.statuses, "status", nil, fmt.Sprintf("events you responded to with the status, one of %s", keys(responseStatuses)))
+	cmd.Flags().StringSliceVar(&f.types, "type", nil, fmt.Sprintf("events of the type, one of %s", keys(eventTypes)))
+	cmd.Flags().DurationVar(&f.minDuration, "min-duration", 0, "events at least that long (e.g 30m)")
+	cmd.Flags().BoolVar(&f.hasMeet, "has-meet", false, "events with a video conference")
+	cmd.Flags().StringSliceVar(&f.tags, "tag", nil, "events with the tag of the rules, repeat for events with all the tags")
+}
+
+func (f *eventFilter) validate() error {
+	for _, s := range f.statuses {
+		if _, ok := responseStatuses[s]; !ok {
+			return fmt.Errorf("--status must be one of %s, got %q", keys(responseStatuses), s)
+		}
+	}
+	for _, t := range f.types {
+		if _, ok := eventTypes[t]; !ok {
+			return fmt.Errorf("--type must be one of %s, got %q", keys(eventTypes), t)
+		}
+	}
+	if f.minDuration < 0 {
+		return fmt.Errorf("--min-duration must not be negative")
+	}
+	return nil
+}
+
+// responseStatus is the response of the user to the event, the events of
+// the user without attendees are accepted
+func responseStatus(e *calendar.Event) string {
+	for _, a := range e.Attendees {
+		if a.Self {
+			return a.ResponseStatus
+		}
+	}
+	return "accepted"
+}
+
+// containsAny tells if the value contains any of the parts, ignoring case
+func containsAny(value string, parts []string) bool {
+	for _, p := range parts {
+		if strings.Contains(strings.ToLower(value), strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches tells if the event passes the filters which are applied locally,
+// the query is applied by the calendar API
+func (f *eventFilter) matches(e *calendar.Event, rules []rule) bool {
+	if len(f.attendees) > 0 {
+		var found bool
+		for _, a := range e.Attendees {
+			if containsAny(a.Email, f.attendees) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.organizers) > 0 && (e.Organizer == nil || !containsAny(e.Organizer.Email, f.organizers)) {
+		return false
+	}
+	if len(f.statuses) > 0 && !contains(f.statuses, responseStatus(e)) {
+		return false
+	}
+	if len(f.types) > 0 {
+		eventType := e.EventType
+		if eventType == "" {
+			eventType = "default"
+		}
+		if !contains(f.types, eventType) {
+			return false
+		}
+	}
+	if f.minDuration > 0 {
+		start, end, err := eventTimes(e)
+		if err != nil || end.Sub(start) < f.minDuration {
+			return false
+		}
+	}
+	if f.hasMeet && e.HangoutLink == "" && e.ConferenceData == nil {
+		return false
+	}
+	if len(f.tags) > 0 && !hasTags(e, rules, f.tags) {
+		return false
+	}
+	return true
+}
+
+func (f *eventFilter) filter(events []*calendar.Event, rules []rule) []*calendar.Event {
+	var matching []*calendar.Event
+	for _, e := range events {
+		if f.matches(e, rules) {
+			matching = append(matching, e)
+		}
+	}
+	return matching
+}
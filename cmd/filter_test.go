@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestEventFilter(t *testing.T) {
+	meeting := existingMeetings([2]time.Time{at(9, 0), at(10, 0)})[0]
+	meeting.Organizer = &calendar.EventOrganizer{Email: "dana@example.com"}
+	meeting.Attendees = []*calendar.EventAttendee{
+		{Email: "dana@example.com"},
+		{Email: "me@example.com", Self: true, ResponseStatus: "tentative"},
+	}
+	meeting.HangoutLink = "https://meet.google.com/abc"
+	short := existingMeetings([2]time.Time{at(11, 0), at(11, 15)})[0]
+	focus := committedFocusEvent("focus", at(12, 0), time.Hour)
+
+	cases := []struct {
+		name   string
+		filter eventFilter
+		wanted []*calendar.Event
+	}{
+		{name: "none", filter: eventFilter{}, wanted: []*calendar.Event{meeting, short, focus}},
+		{name: "attendee", filter: eventFilter{attendees: []string{"DANA"}}, wanted: []*calendar.Event{meeting}},
+		{name: "any attendee", filter: eventFilter{attendees: []string{"joe", "dana"}}, wanted: []*calendar.Event{meeting}},
+		{name: "organizer", filter: eventFilter{organizers: []string{"joe"}}, wanted: nil},
+		{name: "status", filter: eventFilter{statuses: []string{"accepted"}}, wanted: []*calendar.Event{short, focus}},
+		{name: "any status", filter: eventFilter{statuses: []string{"accepted", "tentative"}}, wanted: []*calendar.Event{meeting, short, focus}},
+		{name: "type", filter: eventFilter{types: []string{"default"}}, wanted: []*calendar.Event{meeting, short}},
+		{name: "min duration", filter: eventFilter{minDuration: 30 * time.Minute}, wanted: []*calendar.Event{meeting, focus}},
+		{name: "has meet", filter: eventFilter{hasMeet: true}, wanted: []*calendar.Event{meeting}},
+		{name: "tag", filter: eventFilter{tags: []string{"focus"}}, wanted: []*calendar.Event{focus}},
+		{name: "all filters must match", filter: eventFilter{hasMeet: true, statuses: []string{"accepted"}}, wanted: nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, tc.filter.validate())
+			assert.Equal(t, tc.wanted, tc.filter.filter([]*calendar.Event{meeting, short, focus}, defaultRules))
+		})
+	}
+}
+
+func TestEventFilterValidation(t *testing.T) {
+	assert.Error(t, (&eventFilter{statuses: []string{"maybe"}}).validate())
+	assert.Error(t, (&eventFilter{types: []string{"birthday"}}).validate())
+	assert.Error(t, (&eventFilter{minDuration: -time.Minute}).validate())
+}
+
+func TestParseDay(t *testing.T) {
+	now := at(10, 0)
+	d, err := parseDay(now, "2023-01-31")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), d)
+	d, err = parseDay(now, "-30")
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -30), d)
+	_, err = parseDay(now, "someday")
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// autoDeclineModes maps the --auto-decline values to the calendar API values
+var autoDeclineModes = map[string]string{
+	"none": "declineNone",
+	"all":  "declineAllConflictingInvitations",
+	"new":  "declineOnlyNewConflictingInvitations",
+}
+
+// chatStatuses maps the --chat-status values to the calendar API values
+var chatStatuses = map[string]string{
+	"available": "available",
+	"dnd":       "doNotDisturb",
+}
+
+// focusSettings are the properties of the focus events calgo creates, set
+// from the flags and the focus section of the config file
+var focusSettings = defaultFocus()
+
+func defaultFocus() Focus {
+	f := Focus{}
+	if err := applyDefaults(&f); err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// loadFocusSettings reads the focus settings from the config, flags are
+// bound to the config keys
+func loadFocusSettings() (Focus, error) {
+	f := Focus{
+		Title:          viper.GetString("focus.title"),
+		AutoDecline:    viper.GetString("focus.autoDecline"),
+		DeclineMessage: viper.GetString("focus.declineMessage"),
+		ChatStatus:     viper.GetString("focus.chatStatus"),
+	}
+	if err := applyDefaults(&f); err != nil {
+		return f, err
+	}
+	if _, ok := autoDeclineModes[f.AutoDecline]; !ok {
+		return f, fmt.Errorf("auto decline must be one of %s, got %q", keys(autoDeclineModes), f.AutoDecline)
+	}
+	if _, ok := chatStatuses[f.ChatStatus]; !ok {
+		return f, fmt.Errorf("chat status must be one of %s, got %q", keys(chatStatuses), f.ChatStatus)
+	}
+	return f, nil
+}
+
+func keys(m map[string]string) string {
+	k := make([]string, 0, len(m))
+	for key := range m {
+		k = append(k, key)
+	}
+	sort.Strings(k)
+	return strings.Join(k, ", ")
+}
+
+// applyDefaults sets the zero value fields of the struct v points to, to
+// the value of their default tag
+func applyDefaults(v interface{}) error {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		def, ok := rt.Field(i).Tag.Lookup("default")
+		field := rv.Field(i)
+		if !ok || !field.IsZero() || !field.CanSet() {
+			continue
+		}
+		switch field.Interface().(type) {
+		case time.Duration:
+			d, err := time.ParseDuration(def)
+			if err != nil {
+				return fmt.Errorf("bad default of %s: %w", rt.Field(i).Name, err)
+			}
+			field.SetInt(int64(d))
+		case string:
+			field.SetString(def)
+		default:
+			return fmt.Errorf("default of %s is of unsupported type %s", rt.Field(i).Name, field.Type())
+		}
+	}
+	return nil
+}
+
+// asBusyEvent turns a focus time event to a regular event which shows as
+// busy, for calendars which don't support focus time
+func asBusyEvent(e *calendar.Event) *calendar.Event {
+	busy := *e
+	busy.EventType = "default"
+	busy.FocusTimeProperties = nil
+	busy.Transparency = "opaque"
+	return &busy
+}
+
+// withBusyFallback wraps an event inserter so focus time events which the
+// calendar refuses, e.g on secondary calendars or on accounts without focus
+// time, are created as busy events
+func withBusyFallback(eventInserter func(event *calendar.Event) (*calendar.Event, error)) func(event *calendar.Event) (*calendar.Event, error) {
+	return func(event *calendar.Event) (*calendar.Event, error) {
+		created, err := eventInserter(event)
+		var apiErr *googleapi.Error
+		if event.EventType == "focusTime" && errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest {
+			log.Printf("focus time is not supported by the calendar, creating %q as a busy event: %v\n", event.Summary, err)
+			return eventInserter(asBusyEvent(event))
+		}
+		return created, err
+	}
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	f := Focus{Title: "deep work"}
+	assert.NoError(t, applyDefaults(&f))
+	assert.Equal(t, Focus{
+		Duration:    45 * time.Minute,
+		Title:       "deep work",
+		AutoDecline: "none",
+		ChatStatus:  "available",
+	}, f)
+}
+
+func TestLoadFocusSettings(t *testing.T) {
+	defer viper.Reset()
+	viper.Set("focus.autoDecline", "new")
+	viper.Set("focus.declineMessage", "in focus, ping me later")
+	viper.Set("focus.chatStatus", "dnd")
+
+	f, err := loadFocusSettings()
+	assert.NoError(t, err)
+	assert.Equal(t, "Focus Time", f.Title)
+
+	defer func(f Focus) { focusSettings = f }(focusSettings)
+	focusSettings = f
+	e := newFocusEvent(at(8, 0), time.Hour)
+	assert.Equal(t, &calendar.EventFocusTimeProperties{
+		AutoDeclineMode: "declineOnlyNewConflictingInvitations",
+		DeclineMessage:  "in focus, ping me later",
+		ChatStatus:      "doNotDisturb",
+	}, e.FocusTimeProperties)
+
+	viper.Set("focus.autoDecline", "sometimes")
+	_, err = loadFocusSettings()
+	assert.Error(t, err)
+}
+
+func TestBusyFallback(t *testing.T) {
+	var inserted []*calendar.Event
+	insert := withBusyFallback(func(event *calendar.Event) (*calendar.Event, error) {
+		if event.EventType == "focusTime" {
+			return nil, &googleapi.Error{Code: http.StatusBadRequest}
+		}
+		inserted = append(inserted, event)
+		return event, nil
+	})
+
+	_, err := insert(newFocusEvent(at(8, 0), time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, inserted, 1)
+	assert.Equal(t, "default", inserted[0].EventType)
+	assert.Equal(t, "opaque", inserted[0].Transparency)
+	assert.Nil(t, inserted[0].FocusTimeProperties)
+	assert.Equal(t, kindFocus, calgoKind(inserted[0]), "calgo still owns the busy event")
+}
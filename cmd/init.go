@@ -16,12 +16,21 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
+	Example: "$ calgo init --contacts # also complete attendees from your contacts and directory",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if initContacts {
+			google_calendar.Authorize(google_calendar.ContactsScopes...)
+			return nil
+		}
 		google_calendar.Service()
 		return nil
 	},
 }
 
+// initContacts grants reading the contacts, for completing attendees
+var initContacts bool
+
 func init() {
+	initCmd.Flags().BoolVar(&initContacts, "contacts", false, "grant reading your contacts and directory, asks for a new token")
 	rootCmd.AddCommand(initCmd)
 }
@@ -4,9 +4,11 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/rgolangh/calgo/internal/google_calendar"
@@ -18,9 +20,14 @@ import (
 const dateExpressionRegex = "^(s|m|t|w|th|f|sa)|(-?\\d+(-\\d+)?)$"
 
 var (
-	calendarID string
+	calendarID    string
+	listCalendars []string
+	listTemplate  string
 )
 
+// dayFormat is used to present a day in plans and reports
+const dayFormat = "Mon 02 Jan"
+
 const maxEvents = 10
 const sortField = "startTime"
 const showDeleted = false
@@ -30,61 +37,123 @@ var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List events",
 	Long: fmt.Sprintf(`List events from a calendar with a default number of %d
-and sorted by their %s
+and sorted by their %s. The filters are combined, an event must match all of
+them, and any of the values of a repeated filter.
 	`, maxEvents, sortField),
+	Example: `$ calgo list m-f --attendee dana --attendee joe --min-duration 1h
+$ calgo list --status needsAction --has-meet
+$ calgo list --calendar primary --calendar team@example.com`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return nil
-		}
-		// first arg is either a day expression, or a range if it has a hyphen
-		compile, err := regexp.Compile(dateExpressionRegex)
-		if err != nil {
+		if err := validateDateExpressionArgs(args); err != nil {
 			return err
 		}
-		if !compile.MatchString(args[0]) {
-			return fmt.Errorf("first argument is not a day or range expression")
-		}
-		return nil
+		return listFilter.validate()
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tmin, tmax, err := getTimeBoundaries(args)
 		if err != nil {
 			return err
 		}
-		srv := google_calendar.Service()
-
-		events, err := srv.Events.List(calendarID).
-			ShowDeleted(showDeleted).
-			SingleEvents(true).
-			TimeMin(tmin.Format(time.RFC3339)).
-			TimeMax(tmax.Format(time.RFC3339)).
-			MaxResults(maxEvents).
-			OrderBy(sortField).
-			Do()
+		return listEvents(tmin, tmax, maxEvents, false)
+	},
+}
+
+// listEvents prints the events matching the list filters, up to limit
+// events unless it is zero
+func listEvents(tmin, tmax time.Time, limit int, withDay bool) error {
+	var err error
+	tagRules, err = loadRules()
+	if err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if listTemplate != "" {
+		tmpl, err = template.New("list").Parse(listTemplate)
 		if err != nil {
-			log.Fatalf("Unable to retrieve next ten of the user's events: %v", err)
+			return fmt.Errorf("invalid --template: %w", err)
 		}
+	}
+	srv := google_calendar.Service()
+	calendars, labels, err := agendaCalendars(srv)
+	if err != nil {
+		return err
+	}
+	events, calendarOf, err := fetchEvents(srv, calendars, tmin, tmax, listFilter.query)
+	if err != nil {
+		return err
+	}
+	events = listFilter.filter(events, tagRules)
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
 
-		fmt.Printf("Upcoming events(%d):\n", len(events.Items))
-		if len(events.Items) == 0 {
+	if tmpl != nil {
+		for i, item := range events {
+			te := newTemplateEvent(i+1, item)
+			te.Calendar = calendarOf[item]
+			if err := tmpl.Execute(os.Stdout, te); err != nil {
+				return err
+			}
+			fmt.Println()
+		}
+	} else {
+		fmt.Printf("Upcoming events(%d):\n", len(events))
+		if len(events) == 0 {
 			fmt.Println("No upcoming events found.")
-		} else {
-			for _, item := range events.Items {
-				fmt.Printf(eventString(item))
+		}
+		for i, item := range events {
+			day := ""
+			if withDay {
+				start, _, _ := eventTimes(item)
+				day = start.Format(dayFormat) + " "
+			}
+			prefix := ""
+			if len(calendars) > 1 {
+				prefix = labels.prefix(calendarOf[item])
 			}
+			fmt.Printf("%2d. %s%s%s", i+1, day, prefix, eventString(item))
 		}
+	}
+	// events are selected by their index by commands like edit or rm
+	if err := saveLastList(events, calendarOf); err != nil {
+		log.Printf("failed saving the listed events: %v\n", err)
+	}
+	return nil
+}
+
+// addListFlags adds the flags of the commands listing events
+func addListFlags(cmd *cobra.Command) {
+	addFilterFlags(cmd, &listFilter)
+	cmd.Flags().StringSliceVar(&listCalendars, "calendar", nil, "id of a calendar to list, repeat for several (default the --calendar-id)")
+	cmd.Flags().BoolVar(&allCalendars, "all-calendars", false, "list all the calendars selected in the calendar list")
+	cmd.Flags().StringVar(&listTemplate, "template", "", "go template of each event, e.g '{{.Start.Format \"15:04\"}} {{.Summary}} {{.Tags}}'")
+}
+
+// validateDateExpressionArgs makes sure the optional first argument is a day
+// or range expression
+func validateDateExpressionArgs(args []string) error {
+	if len(args) == 0 {
 		return nil
-	},
+	}
+	// first arg is either a day expression, or a range if it has a hyphen
+	compile, err := regexp.Compile(dateExpressionRegex)
+	if err != nil {
+		return err
+	}
+	if !compile.MatchString(args[0]) {
+		return fmt.Errorf("first argument is not a day or range expression")
+	}
+	return nil
 }
 
 func getTimeBoundaries(args []string) (time.Time, time.Time, error) {
 	var tmin, tmax time.Time
 	var err error = nil
 	if len(args) == 0 {
-		tmin = time.Now()
-		tmax = time.Now()
+		tmin = clock()
+		tmax = clock()
 	} else {
-		tmin, tmax, err = parseDatetimeExpression(time.Now(), args[0])
+		tmin, tmax, err = parseDatetimeExpression(clock(), args[0])
 		if err != nil {
 			return tmin, tmax, err
 		}
@@ -102,6 +171,16 @@ func endOfDay(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 20, 0, 0, 0, t.Location())
 }
 
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
 // parseDatetimeExpression get a day, or range expression in the form of
 // n or n-n where n is the day and return 2 datetime representing
 // the min start time and max start time of meeting to search for
@@ -189,22 +268,53 @@ func timeFromExpression(pointInTime time.Time, s string) (time.Time, error) {
 }
 
 func eventString(e *calendar.Event) string {
-	parse, err := time.Parse(time.RFC3339, e.Start.DateTime)
+	parse, end, err := eventTimes(e)
 	if err != nil {
 		fmt.Println(err)
 	}
-	end, err := time.Parse(time.RFC3339, e.End.DateTime)
-	if err != nil {
-		fmt.Println(err)
+	when := fmt.Sprintf("%s - %s", parse.Format(time.Kitchen), end.Format(time.Kitchen))
+	if e.Start.DateTime == "" {
+		when = "all day"
 	}
 	var n = ""
 	if len(e.Id) == 0 {
 		n = "[+]"
+	} else if isRecurring(e) {
+		// an instance of a series, see calgo series
+		n = "↻"
 	}
-	return fmt.Sprintf("%-17s - %-3s %v\n", fmt.Sprintf("%s - %s", parse.Format(time.Kitchen), end.Format(time.Kitchen)), n, e.Summary)
+	return fmt.Sprintf("%-17s - %-3s %s%v\n", when, n, eventTypeLabel(e), e.Summary)
+}
+
+// eventTypeLabel tells apart the special event types
+func eventTypeLabel(e *calendar.Event) string {
+	switch e.EventType {
+	case "outOfOffice":
+		return "[ooo] "
+	case "workingLocation":
+		return "[" + workingLocationString(e.WorkingLocationProperties) + "] "
+	}
+	return ""
+}
+
+// templateEvent is the event passed to the --template of list, with its
+// times parsed and its tags
+type templateEvent struct {
+	*calendar.Event
+	Index    int
+	Calendar string
+	Start    time.Time
+	End      time.Time
+	Tags     []string
+}
+
+func newTemplateEvent(index int, e *calendar.Event) templateEvent {
+	start, end, _ := eventTimes(e)
+	return templateEvent{Event: e, Index: index, Start: start, End: end, Tags: eventTags(e, tagRules)}
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().StringVar(&calendarID, "calendar-id", "primary", "id of the calendar")
+	addListFlags(listCmd)
 }
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// defaultMeetingDuration is offered when asking for the duration of a meeting
+const defaultMeetingDuration = 50 * time.Minute
+
+// planMeetingFlags are the meetings to schedule given with --meeting
+var planMeetingFlags []string
+
+// parseMeeting parses a meeting in the form of TITLE;DURATION[;EMAIL,EMAIL],
+// the aliases among the attendees are expanded
+func parseMeeting(s string, aliases map[string][]string) (Meeting, error) {
+	parts := strings.Split(s, ";")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Meeting{}, fmt.Errorf("unsupported meeting %q, use the form of 'TITLE;DURATION;EMAIL,EMAIL'", s)
+	}
+	m := Meeting{Title: strings.TrimSpace(parts[0])}
+	if m.Title == "" {
+		return m, fmt.Errorf("meeting %q has no title", s)
+	}
+	d, err := parsePositiveDuration(strings.TrimSpace(parts[1]), 0)
+	if err != nil {
+		return m, fmt.Errorf("meeting %q: %w", s, err)
+	}
+	m.Duration = d
+	if len(parts) == 3 {
+		emails, err := expandAttendees(strings.Split(parts[2], ","), aliases)
+		if err != nil {
+			return m, fmt.Errorf("meeting %q: %w", s, err)
+		}
+		for _, email := range emails {
+			m.Attendees = append(m.Attendees, &calendar.EventAttendee{Email: email})
+		}
+	}
+	return m, nil
+}
+
+// parseMeetings parses the --meeting flags
+func parseMeetings(values []string, aliases map[string][]string) ([]Meeting, error) {
+	var meetings []Meeting
+	for _, v := range values {
+		m, err := parseMeeting(v, aliases)
+		if err != nil {
+			return nil, err
+		}
+		meetings = append(meetings, m)
+	}
+	return meetings, nil
+}
+
+// validateEmail makes sure an attendee looks like an email address
+func validateEmail(email string) error {
+	at := strings.Index(email, "@")
+	if at <= 0 || at == len(email)-1 || strings.ContainsAny(email, " \t,;") {
+		return fmt.Errorf("%q is not an email address", email)
+	}
+	return nil
+}
+
+// newMeetingEvent creates the event of a meeting, stamped so unplan and
+// --replace find it
+func newMeetingEvent(m Meeting, start time.Time) *calendar.Event {
+	return stampKind(&calendar.Event{
+		Summary:     m.Title,
+		Description: m.Description,
+		Attendees:   m.Attendees,
+		Start:       &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:         &calendar.EventDateTime{DateTime: start.Add(m.Duration).Format(time.RFC3339)},
+	}, kindMeeting)
+}
+
+func attendeeEmails(m Meeting) []string {
+	emails := make([]string, 0, len(m.Attendees))
+	for _, a := range m.Attendees {
+		emails = append(emails, a.Email)
+	}
+	return emails
+}
+
+// withoutBusy removes the busy periods from the free slots
+func withoutBusy(free []Slot, busy []Slot) []Slot {
+	for _, b := range busy {
+		var remaining []Slot
+		for _, f := range free {
+			if !b.StartTime.Before(f.EndTime) || !b.EndTime.After(f.StartTime) {
+				remaining = append(remaining, f)
+				continue
+			}
+			if b.StartTime.After(f.StartTime) {
+				remaining = append(remaining, Slot{StartTime: f.StartTime, EndTime: b.StartTime})
+			}
+			if b.EndTime.Before(f.EndTime) {
+				remaining = append(remaining, Slot{StartTime: b.EndTime, EndTime: f.EndTime})
+			}
+		}
+		free = remaining
+	}
+	return free
+}
+
+// findMeetingSlot finds the earliest slot on the days of the plan which is
+// free for the user and not in any of the busy periods of the attendees
+func (p *Plan) findMeetingSlot(duration time.Duration, busy []Slot) (Slot, bool) {
+	for _, day := range p.days() {
+		free, err := p.freeSlots(day)
+		if err != nil {
+			continue
+		}
+		for _, s := range withoutBusy(free, busy) {
+			if s.fits(duration) {
+				return s.head(duration), true
+			}
+		}
+	}
+	return Slot{}, false
+}
+
+// planMeetings schedules the meetings of the plan, before the focus time
+// takes up the free slots
+func (p *Plan) planMeetings() error {
+	days := p.days()
+	tmin, tmax := startOfDay(days[0]), endOfDay(days[len(days)-1])
+	for i, m := range p.meetings {
+		var busy []Slot
+		if emails := attendeeEmails(m); len(emails) > 0 && p.attendeesBusy != nil {
+			var err error
+			busy, err = p.attendeesBusy(emails, tmin, tmax)
+			if err != nil {
+				return fmt.Errorf("unable to query the availability of the attendees: %w", err)
+			}
+		}
+		slot, ok := p.findMeetingSlot(m.Duration, busy)
+		if !ok {
+			log.Printf("✘ [meeting %d] %s: no free slot of %s for all the attendees\n", i+1, m.Title, m.Duration)
+			continue
+		}
+		p.events.insert(newMeetingEvent(m, slot.StartTime))
+		log.Printf("✔ [meeting %d] scheduled to %s-%s as all attendees are available\n",
+			i+1, slot.StartTime.Format("15:04"), slot.EndTime.Format("15:04"))
+	}
+	return nil
+}
+
+// freeBusy returns the busy periods of the given attendees. Calendars that
+// can't be queried, e.g of other organizations, are considered free.
+func freeBusy(service *calendar.Service) func(emails []string, tmin, tmax time.Time) ([]Slot, error) {
+	return func(emails []string, tmin, tmax time.Time) ([]Slot, error) {
+		req := &calendar.FreeBusyRequest{
+			TimeMin: tmin.Format(time.RFC3339),
+			TimeMax: tmax.Format(time.RFC3339),
+		}
+		for _, email := range emails {
+			req.Items = append(req.Items, &calendar.FreeBusyRequestItem{Id: email})
+		}
+		var resp *calendar.FreeBusyResponse
+		err := withRetry(func() (err error) {
+			resp, err = service.Freebusy.Query(req).Do()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		var busy []Slot
+		for email, c := range resp.Calendars {
+			if len(c.Errors) > 0 {
+				log.Printf("unable to tell the availability of %s: %s\n", email, c.Errors[0].Reason)
+			}
+			for _, period := range c.Busy {
+				start, err := time.Parse(time.RFC3339, period.Start)
+				if err != nil {
+					return nil, err
+				}
+				end, err := time.Parse(time.RFC3339, period.End)
+				if err != nil {
+					return nil, err
+				}
+				busy = append(busy, Slot{StartTime: start, EndTime: end})
+			}
+		}
+		return busy, nil
+	}
+}
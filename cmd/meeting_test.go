@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestParseMeeting(t *testing.T) {
+	m, err := parseMeeting("discuss requirements;50m;dana@example.com, joe@example.com", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Meeting{
+		Title:    "discuss requirements",
+		Duration: 50 * time.Minute,
+		Attendees: []*calendar.EventAttendee{
+			{Email: "dana@example.com"},
+			{Email: "joe@example.com"},
+		},
+	}, m)
+
+	m, err = parseMeeting("sync;1h", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Meeting{Title: "sync", Duration: time.Hour}, m)
+
+	for _, s := range []string{"sync", ";30m", "sync;0m", "sync;-5m", "sync;soon", "sync;30m;dana", "a;b;c;d"} {
+		_, err := parseMeeting(s, nil)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseMeetingExpandsAliases(t *testing.T) {
+	aliases := map[string][]string{"team": {"dana@example.com", "joe@example.com"}}
+	m, err := parseMeeting("retro;30m;team,joe@example.com,ann@example.com", aliases)
+	assert.NoError(t, err)
+	assert.Equal(t, []*calendar.EventAttendee{
+		{Email: "dana@example.com"},
+		{Email: "joe@example.com"},
+		{Email: "ann@example.com"},
+	}, m.Attendees)
+}
+
+func TestWithoutBusy(t *testing.T) {
+	free := []Slot{
+		{StartTime: at(8, 0), EndTime: at(12, 0)},
+		{StartTime: at(13, 0), EndTime: at(20, 0)},
+	}
+	busy := []Slot{
+		{StartTime: at(7, 0), EndTime: at(9, 0)},
+		{StartTime: at(10, 0), EndTime: at(10, 30)},
+		{StartTime: at(11, 30), EndTime: at(14, 0)},
+	}
+	assert.Equal(t, []Slot{
+		{StartTime: at(9, 0), EndTime: at(10, 0)},
+		{StartTime: at(10, 30), EndTime: at(11, 30)},
+		{StartTime: at(14, 0), EndTime: at(20, 0)},
+	}, withoutBusy(free, busy))
+}
+
+func TestPlanMeetings(t *testing.T) {
+	events := newEvents()
+	events.addAll(existingMeetings([2]time.Time{at(8, 0), at(10, 0)}))
+	dana := []*calendar.EventAttendee{{Email: "dana@example.com"}}
+	var queried []string
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: time.Hour,
+		focusDuration:    time.Hour,
+		events:           events,
+		meetings: []Meeting{
+			{Title: "discuss requirements", Duration: 50 * time.Minute, Attendees: dana},
+			{Title: "retro", Duration: 30 * time.Minute},
+		},
+		attendeesBusy: func(emails []string, tmin, tmax time.Time) ([]Slot, error) {
+			queried = append(queried, emails...)
+			return []Slot{{StartTime: at(10, 0), EndTime: at(14, 0)}}, nil
+		},
+	}
+
+	err := p.plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dana@example.com"}, queried)
+	// the meetings are scheduled first, dana is busy until 14:00
+	assert.Equal(t, []*calendar.Event{
+		newMeetingEvent(Meeting{Title: "retro", Duration: 30 * time.Minute}, at(10, 0)),
+		newFocusEvent(at(10, 30), time.Hour),
+		newMeetingEvent(p.meetings[0], at(14, 0)),
+	}, p.getAddedEvents())
+	assert.Equal(t, kindMeeting, calgoKind(p.getAddedEvents()[0]))
+}
+
+func TestPlanMeetingsWithoutSlot(t *testing.T) {
+	events := newEvents()
+	events.addAll(existingMeetings([2]time.Time{at(8, 0), at(19, 30)}))
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: 30 * time.Minute,
+		focusDuration:    30 * time.Minute,
+		events:           events,
+		meetings:         []Meeting{{Title: "offsite", Duration: time.Hour}},
+	}
+
+	err := p.plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []*calendar.Event{newFocusEvent(at(19, 30), 30*time.Minute)}, p.getAddedEvents())
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// movePatch creates a patch moving the event to start at the given time,
+// keeping its duration
+func movePatch(e *calendar.Event, start time.Time) (*calendar.Event, error) {
+	if e.Start.DateTime == "" {
+		return nil, fmt.Errorf("moving all-day events is not supported")
+	}
+	oldStart, oldEnd, err := eventTimes(e)
+	if err != nil {
+		return nil, err
+	}
+	return &calendar.Event{
+		Start: &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:   &calendar.EventDateTime{DateTime: start.Add(oldEnd.Sub(oldStart)).Format(time.RFC3339)},
+	}, nil
+}
+
+// moveCmd changes the time of an event
+var moveCmd = &cobra.Command{
+	Use:   "move SELECTOR DAY EXPRESSION TIME",
+	Short: "Move an event to another day or time",
+	Long: `Move an event, keeping its duration. The event is selected by its index in
+the last list, by a prefix of its id, or by a part of its title.`,
+	Example: `$ calgo move 3 th 15:00
+$ calgo move "1:1 with dana" +1 10am --yes`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 3 {
+			return fmt.Errorf("expected an event selector, a day expression and a time")
+		}
+		return validateDateExpressionArgs(args[1:])
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		day, _, err := parseDatetimeExpression(clock(), args[1])
+		if err != nil {
+			return err
+		}
+		start, err := parseTimeOfDay(day, args[2])
+		if err != nil {
+			return err
+		}
+		srv := google_calendar.Service()
+		event, calendarId, err := selectEvent(srv, args[0])
+		if err != nil {
+			return err
+		}
+		patch, err := movePatch(event, start)
+		if err != nil {
+			return err
+		}
+		fmt.Print(eventString(event))
+		if ok, err := confirmChange(fmt.Sprintf("Move this event to %s %s?", start.Format(dayFormat), start.Format(time.Kitchen))); err != nil || !ok {
+			return err
+		}
+		var moved *calendar.Event
+		changed(calendarId)
+		err = withRetry(func() (err error) {
+			moved, err = srv.Events.Patch(calendarId, event.Id, patch).SendUpdates("all").Do()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("unable to move event: %w", err)
+		}
+		fmt.Printf("%s %s", start.Format(dayFormat), eventString(moved))
+		return nil
+	},
+}
+
+func init() {
+	addSelectorFlags(moveCmd)
+	rootCmd.AddCommand(moveCmd)
+}
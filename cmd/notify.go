@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// notifier shows a desktop notification
+type notifier func(title, body string) error
+
+// dbusNotifier sends notifications to the freedesktop notifications service
+// of the session bus
+func dbusNotifier() (notifier, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("no session bus for notifications, use --notify-command instead: %w", err)
+	}
+	obj := conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+	return func(title, body string) error {
+		call := obj.Call("org.freedesktop.Notifications.Notify", 0,
+			"calgo", uint32(0), "", title, body, []string{}, map[string]dbus.Variant{}, int32(-1))
+		return call.Err
+	}, nil
+}
+
+// commandNotifier runs a shell command for each notification, with the
+// title and body in the CALGO_TITLE and CALGO_BODY environment variables
+func commandNotifier(command string) notifier {
+	return func(title, body string) error {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Env = append(os.Environ(), "CALGO_TITLE="+title, "CALGO_BODY="+body)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+}
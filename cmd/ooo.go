@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	oooMessage     string
+	oooTitle       string
+	oooAutoDecline string
+)
+
+// newOutOfOfficeEvent creates an out of office event over whole days, from
+// the midnight of start to the midnight after end
+func newOutOfOfficeEvent(start, end time.Time, title, autoDecline, message string) *calendar.Event {
+	return stampKind(&calendar.Event{
+		Summary:      title,
+		EventType:    "outOfOffice",
+		Transparency: "opaque",
+		OutOfOfficeProperties: &calendar.EventOutOfOfficeProperties{
+			AutoDeclineMode: autoDeclineModes[autoDecline],
+			DeclineMessage:  message,
+		},
+		Start: &calendar.EventDateTime{
+			DateTime: midnight(start).Format(time.RFC3339),
+		},
+		End: &calendar.EventDateTime{
+			DateTime: midnight(end).AddDate(0, 0, 1).Format(time.RFC3339),
+		},
+	}, kindOutOfOffice)
+}
+
+// oooCmd creates out of office events
+var oooCmd = &cobra.Command{
+	Use:     "ooo DAY EXPRESSION/RANGE EXPRESSION",
+	Short:   "Set yourself out of office",
+	Long:    `Create an out of office event over whole days, declining invitations on these days. calgo does not plan on out of office days.`,
+	Example: `$ calgo ooo m-w --message "on vacation, back on Thursday"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a day or range expression")
+		}
+		if _, ok := autoDeclineModes[oooAutoDecline]; !ok {
+			return fmt.Errorf("--auto-decline must be one of %s", keys(autoDeclineModes))
+		}
+		return validateDateExpressionArgs(args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, tmax, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		srv := google_calendar.Service()
+		p := eventsPlan(calendarID, srv, []*calendar.Event{
+			newOutOfOfficeEvent(tmin, tmax, oooTitle, oooAutoDecline, oooMessage),
+		})
+		p.date, p.endDate = tmin, tmax
+		log.Println(p)
+		results, err := p.commit()
+		fmt.Print(results)
+		return err
+	},
+}
+
+func init() {
+	oooCmd.Flags().StringVar(&oooMessage, "message", "", "message to send with the declined invitations")
+	oooCmd.Flags().StringVar(&oooTitle, "title", "Out of office", "title of the out of office event")
+	oooCmd.Flags().StringVar(&oooAutoDecline, "auto-decline", "all", fmt.Sprintf("auto decline invitations on these days, one of %s", keys(autoDeclineModes)))
+	oooCmd.Flags().BoolVar(&interactive, "interactive", true, "Ask before committing changes")
+	rootCmd.AddCommand(oooCmd)
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestOutOfOfficeEvent(t *testing.T) {
+	e := newOutOfOfficeEvent(at(8, 0), at(20, 0).AddDate(0, 0, 1), "Out of office", "all", "on vacation")
+
+	start, end, err := eventTimes(e)
+	assert.NoError(t, err)
+	assert.Equal(t, at(0, 0), start)
+	assert.Equal(t, at(0, 0).AddDate(0, 0, 2), end)
+	assert.Equal(t, "declineAllConflictingInvitations", e.OutOfOfficeProperties.AutoDeclineMode)
+	assert.Equal(t, kindOutOfOffice, calgoKind(e))
+	assert.Contains(t, eventString(e), "[ooo] Out of office")
+}
+
+func TestPlanSkipsOutOfOfficeDays(t *testing.T) {
+	monday := at(0, 0)
+	events := newEvents()
+	ooo := newOutOfOfficeEvent(monday, monday, "Out of office", "all", "")
+	ooo.Id = "ooo"
+	events.insert(ooo)
+	// an all-day out of office event created by other means
+	events.insert(&calendar.Event{
+		Id:        "ooo2",
+		EventType: "outOfOffice",
+		Start:     &calendar.EventDateTime{Date: "2023-09-26"},
+		End:       &calendar.EventDateTime{Date: "2023-09-27"},
+	})
+	p := &Plan{
+		date:             monday.In(time.Local),
+		endDate:          endOfDay(monday.AddDate(0, 0, 2).In(time.Local)),
+		overallFocusTime: time.Hour,
+		focusDuration:    time.Hour,
+		events:           events,
+	}
+
+	assert.NoError(t, p.plan())
+	added := p.getAddedEvents()
+	assert.Len(t, added, 1)
+	start, _, _ := eventTimes(added[0])
+	assert.Equal(t, 27, start.Day(), "planned on the first day which is not out of office")
+}
@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// addOutputFlag adds the --output flag to a command, for choosing between
+// a human readable and a machine readable output
+func addOutputFlag(cmd *cobra.Command, format *string) {
+	cmd.Flags().StringVarP(format, "output", "o", outputText, fmt.Sprintf("output format, either %s or %s", outputText, outputJSON))
+}
+
+func validateOutputFormat(format string) error {
+	if format != outputText && format != outputJSON {
+		return fmt.Errorf("--output must be either %s or %s", outputText, outputJSON)
+	}
+	return nil
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
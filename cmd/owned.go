@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Events created by calgo are stamped with private extended properties, so
+// calgo can recognize its own events when planning again or removing them.
+const (
+	calgoPlanIdProperty  = "calgoPlanId"
+	calgoKindProperty    = "calgoKind"
+	calgoVersionProperty = "calgoVersion"
+	// calgoVersion is bumped when the meaning of the properties changes
+	calgoVersion = "1"
+)
+
+// kinds of events created by calgo
+const (
+	kindFocus           = "focus"
+	kindMeeting         = "meeting"
+	kindOutOfOffice     = "ooo"
+	kindWorkingLocation = "workingLocation"
+)
+
+// newPlanId creates a unique id for the events committed together
+func newPlanId(t time.Time) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return t.Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// stampKind marks the event as created by calgo, of the given kind
+func stampKind(e *calendar.Event, kind string) *calendar.Event {
+	if e.ExtendedProperties == nil {
+		e.ExtendedProperties = &calendar.EventExtendedProperties{}
+	}
+	if e.ExtendedProperties.Private == nil {
+		e.ExtendedProperties.Private = map[string]string{}
+	}
+	e.ExtendedProperties.Private[calgoKindProperty] = kind
+	e.ExtendedProperties.Private[calgoVersionProperty] = calgoVersion
+	return e
+}
+
+// stampPlan marks the event as committed by the given plan
+func stampPlan(e *calendar.Event, planId string) {
+	if !isCalgoEvent(e) {
+		stampKind(e, "")
+	}
+	e.ExtendedProperties.Private[calgoPlanIdProperty] = planId
+}
+
+func isCalgoEvent(e *calendar.Event) bool {
+	if e.ExtendedProperties == nil || e.ExtendedProperties.Private == nil {
+		return false
+	}
+	_, ok := e.ExtendedProperties.Private[calgoVersionProperty]
+	return ok
+}
+
+// calgoKind returns the kind of an event created by calgo, empty otherwise
+func calgoKind(e *calendar.Event) string {
+	if !isCalgoEvent(e) {
+		return ""
+	}
+	return e.ExtendedProperties.Private[calgoKindProperty]
+}
+
+// isCalgoFocusEvent tells if this is a persisted focus event of calgo
+func isCalgoFocusEvent(e *calendar.Event) bool {
+	return e.Id != "" && calgoKind(e) == kindFocus
+}
+
+// isCalgoPlannedEvent tells if this is a persisted focus event or meeting
+// which calgo planned, those are deleted when planning with --replace
+func isCalgoPlannedEvent(e *calendar.Event) bool {
+	kind := calgoKind(e)
+	return e.Id != "" && (kind == kindFocus || kind == kindMeeting)
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+// committedFocusEvent is a focus event calgo created on a previous plan
+func committedFocusEvent(id string, start time.Time, duration time.Duration) *calendar.Event {
+	e := newFocusEvent(start, duration)
+	e.Id = id
+	stampPlan(e, "previous")
+	return e
+}
+
+func TestStamp(t *testing.T) {
+	e := newFocusEvent(at(8, 0), time.Hour)
+	assert.True(t, isCalgoEvent(e))
+	assert.Equal(t, kindFocus, calgoKind(e))
+
+	stampPlan(e, "plan1")
+	assert.Equal(t, "plan1", e.ExtendedProperties.Private[calgoPlanIdProperty])
+	assert.Equal(t, calgoVersion, e.ExtendedProperties.Private[calgoVersionProperty])
+
+	assert.False(t, isCalgoEvent(existingMeetings([2]time.Time{at(8, 0), at(9, 0)})[0]))
+}
+
+func TestIsCalgoPlannedEvent(t *testing.T) {
+	meeting := newMeetingEvent(Meeting{Title: "retro", Duration: 30 * time.Minute}, at(10, 0))
+	assert.False(t, isCalgoPlannedEvent(meeting), "not persisted yet")
+	meeting.Id = "m1"
+	stampPlan(meeting, "previous")
+	assert.True(t, isCalgoPlannedEvent(meeting))
+	assert.Equal(t, "previous", meeting.ExtendedProperties.Private[calgoPlanIdProperty])
+
+	assert.True(t, isCalgoPlannedEvent(committedFocusEvent("f1", at(8, 0), time.Hour)))
+	assert.False(t, isCalgoPlannedEvent(existingMeetings([2]time.Time{at(8, 0), at(9, 0)})[0]))
+}
+
+func TestPlanReusesCalgoEvents(t *testing.T) {
+	events := newEvents()
+	events.insert(committedFocusEvent("f1", at(8, 0), time.Hour))
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: 2 * time.Hour,
+		focusDuration:    time.Hour,
+		events:           events,
+	}
+
+	assert.NoError(t, p.plan())
+	assert.Equal(t, []*calendar.Event{newFocusEvent(at(9, 0), time.Hour)}, p.getAddedEvents())
+	assert.Equal(t, time.Hour, p.reusedFocusTime)
+	assert.Equal(t, 2*time.Hour, p.scheduledFocusTime)
+}
+
+func TestCommitDeletesReplacedEvents(t *testing.T) {
+	nonInteractiveCommit(t)
+	f := &fakeCalendar{}
+	p := planWithFocusEvents(f, 1)
+	p.id = "plan1"
+	p.replaced = []*calendar.Event{committedFocusEvent("old", at(12, 0), time.Hour)}
+
+	_, err := p.commit()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id1"}, f.inserted)
+	assert.Equal(t, []string{"old"}, f.deleted)
+}
+
+func TestCommitStampsPlanId(t *testing.T) {
+	nonInteractiveCommit(t)
+	f := &fakeCalendar{}
+	p := planWithFocusEvents(f, 1)
+	p.id = "plan1"
+	added := p.getAddedEvents()
+
+	_, err := p.commit()
+	assert.NoError(t, err)
+	assert.Equal(t, "plan1", added[0].ExtendedProperties.Private[calgoPlanIdProperty])
+}
+
+func TestUnplan(t *testing.T) {
+	nonInteractiveCommit(t)
+	events := append(existingMeetings([2]time.Time{at(8, 0), at(9, 0)}),
+		committedFocusEvent("f1", at(9, 0), time.Hour),
+		committedFocusEvent("f2", at(10, 0), time.Hour),
+	)
+	var deleted []string
+
+	err := unplan(calgoEvents(events), func(id string) error {
+		deleted = append(deleted, id)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f1", "f2"}, deleted)
+}
+
+func TestListCalgoEventsOfAllPages(t *testing.T) {
+	events := append(existingMeetings([2]time.Time{at(8, 0), at(9, 0)}),
+		committedFocusEvent("f1", at(9, 0), time.Hour),
+		committedFocusEvent("f2", at(10, 0), time.Hour),
+		committedFocusEvent("f3", at(11, 0), time.Hour),
+	)
+	standIn := &listStandIn{events: map[string][]*calendar.Event{"primary": events}, pageSize: 2}
+
+	owned, err := listCalgoEvents(listStandInService(t, standIn), "primary", at(0, 0), at(23, 0))
+	assert.NoError(t, err)
+	var ids []string
+	for _, e := range owned {
+		ids = append(ids, e.Id)
+	}
+	assert.Equal(t, []string{"f1", "f2", "f3"}, ids)
+	assert.Len(t, standIn.queries, 2)
+	assert.Equal(t, calgoVersionProperty+"="+calgoVersion, standIn.queries[0].Get("privateExtendedProperty"))
+}
@@ -4,11 +4,13 @@ package cmd
 import (
 	"bytes"
 	"fmt"
-	"github.com/AlecAivazis/survey/v2"
+	pretty "github.com/jedib0t/go-pretty/v6/text"
 	"github.com/rgolangh/calgo/internal/google_calendar"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"google.golang.org/api/calendar/v3"
 	"log"
+	"os"
 	"time"
 )
 
@@ -17,17 +19,41 @@ var (
 	meetingsTime       time.Duration
 	focusTime          time.Duration
 	focusEventDuration time.Duration
+	minFocusBlock      time.Duration
+	maxFocusPerDay     time.Duration
+	balance            string
+	strategy           string
+	dryRun             bool
+	showDiff           bool
+	planOutput         string
+	replaceFocus       bool
+	commitWorkers      int
+	commitRate         float64
 	tasks              time.Duration
 )
 
+const defaultStrategy = "earliest"
+
+const (
+	// balanceEven spreads the focus time evenly on the days of the range
+	balanceEven = "even"
+	// balanceFront fills up the first days of the range before moving on
+	balanceFront = "front"
+)
+
 type Slot struct {
-	StartTime time.Time
-	EndTime   time.Time
+	StartTime time.Time `json:"start"`
+	EndTime   time.Time `json:"end"`
 }
 
 type Focus struct {
-	Duration time.Duration
-	Title    string `default:"Focus Time"`
+	Duration time.Duration `default:"45m"`
+	Title    string        `default:"Focus Time"`
+	// AutoDecline of conflicting invitations, one of autoDeclineModes
+	AutoDecline    string `default:"none"`
+	DeclineMessage string
+	// ChatStatus while in focus, one of chatStatuses
+	ChatStatus string `default:"available"`
 }
 type Meeting struct {
 	Duration    time.Duration
@@ -38,184 +64,456 @@ type Meeting struct {
 
 type Plan struct {
 	eventInserter func(event *calendar.Event) (*calendar.Event, error)
-	calendarId    string
+	// eventDeleter removes a committed event, when rolling back a commit
+	eventDeleter func(id string) error
+	// workers is the number of events inserted concurrently on commit
+	workers int
+	// rate is the maximum number of inserts per second, zero for no limit
+	rate float64
+	// id stamped on the events committed by this plan
+	id string
+	// replaced are focus events and meetings calgo created before, which are deleted
+	// on commit in favour of the events of this plan
+	replaced []*calendar.Event
+	// now tells the current time, the package clock is used if not set
+	now        func() time.Time
+	calendarId string
 	// target date of plan, either today or future
-	date             time.Time
+	date time.Time
+	// last day of the plan when planning a range, zero for a single day plan
+	endDate          time.Time
 	events           Events
 	overallFocusTime time.Duration
-	focusDuration    time.Duration
-	slots            []Slot
-	focuses          []Focus
-	meetings         []Meeting
+	// scheduledFocusTime is how much of the overall focus time was planned
+	scheduledFocusTime time.Duration
+	// reusedFocusTime is the part of the scheduled focus time which calgo
+	// already created on a previous plan
+	reusedFocusTime time.Duration
+	// focusDuration is the longest focus event to plan
+	focusDuration time.Duration
+	// minFocusDuration is the shortest focus event to plan, zero to plan
+	// fixed focusDuration blocks
+	minFocusDuration time.Duration
+	// maximum focus time to plan for a single day, zero means no cap
+	maxFocusPerDay time.Duration
+	balance        string
+	// name of the slot strategy, see slotStrategies
+	strategy string
+	// recurrence is the RRULE of the planned focus events, empty for
+	// single events
+	recurrence string
+	slots      []Slot
+	// meetings are scheduled before the focus time, where the attendees
+	// are available too
+	meetings []Meeting
+	// attendeesBusy returns the busy periods of the attendees of a meeting
+	attendeesBusy func(emails []string, tmin, tmax time.Time) ([]Slot, error)
+	// confirmed is set when the user confirmed the plan already, e.g in the
+	// terminal UI, so committing it doesn't ask again even when interactive
+	confirmed bool
 }
 
-func newPlan(calId string, service *calendar.Service) *Plan {
-	events, err := service.Events.List(calendarID).
-		ShowDeleted(false).
-		SingleEvents(true).
-		TimeMin(time.Now().Format(time.RFC3339)).
-		TimeMax(endOfDay(time.Now()).Format(time.RFC3339)).
-		OrderBy("startTime").
-		Do()
+func newPlan(calId string, service *calendar.Service, tmin, tmax time.Time) (*Plan, error) {
+	items, err := cachedEvents(service, calendarID, tmin, tmax)
 	if err != nil {
-		log.Fatalf("Unable to retrieve next ten of the user's events: %v", err)
+		return nil, fmt.Errorf("unable to retrieve the events to plan: %w", err)
 	}
-	eventInserter := func(event *calendar.Event) (*calendar.Event, error) {
-		return service.Events.Insert(calendarID, event).Do()
+	eventInserter := withBusyFallback(func(event *calendar.Event) (*calendar.Event, error) {
+		return insertEvent(service, calendarID, event)
+	})
+	eventDeleter := func(id string) error {
+		// attendees of replaced meetings are told they are cancelled
+		changed(calendarID)
+		return service.Events.Delete(calendarID, id).SendUpdates("all").Do()
 	}
 
 	plannedEvents := newEvents()
-	plannedEvents.addAll(events.Items)
+	var replaced []*calendar.Event
+	for _, e := range items {
+		if replaceFocus && isCalgoPlannedEvent(e) {
+			replaced = append(replaced, e)
+			continue
+		}
+		plannedEvents.insert(e)
+	}
 	return &Plan{
-		date:             time.Now(),
+		id:               newPlanId(clock()),
+		replaced:         replaced,
+		date:             tmin,
+		endDate:          tmax,
 		eventInserter:    eventInserter,
+		eventDeleter:     eventDeleter,
+		workers:          commitWorkers,
+		rate:             commitRate,
+		now:              clock,
 		calendarId:       calId,
 		overallFocusTime: focusTime,
 		focusDuration:    focusEventDuration,
+		minFocusDuration: minFocusBlock,
+		maxFocusPerDay:   maxFocusPerDay,
+		balance:          balance,
+		strategy:         strategy,
 		events:           plannedEvents,
+		attendeesBusy:    freeBusy(service),
+	}, nil
+}
+
+// insertEvent creates an event, sending invitations if it has attendees
+func insertEvent(service *calendar.Service, calendarId string, event *calendar.Event) (*calendar.Event, error) {
+	changed(calendarId)
+	call := service.Events.Insert(calendarId, event)
+	if len(event.Attendees) > 0 {
+		call = call.SendUpdates("all")
+	}
+	return call.Do()
+}
+
+func (p *Plan) currentTime() time.Time {
+	if p.now == nil {
+		return clock()
+	}
+	return p.now()
+}
+
+// days returns the days of the plan, in order
+func (p *Plan) days() []time.Time {
+	days := []time.Time{p.date}
+	if p.endDate.IsZero() {
+		return days
+	}
+	for d := p.date.AddDate(0, 0, 1); !startOfDay(d).After(p.endDate); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	return days
+}
+
+// minBlock is the shortest focus event to plan. Without a minimum, focus
+// events are planned in fixed blocks of focusDuration
+func (p *Plan) minBlock() time.Duration {
+	if p.minFocusDuration == 0 {
+		return p.focusDuration
 	}
+	return p.minFocusDuration
 }
 
 func (p *Plan) plan() error {
-	for focusDurationToAdd := p.overallFocusTime; focusDurationToAdd >= p.focusDuration; focusDurationToAdd -= p.focusDuration {
-		slot, err := p.findNextSlot()
-		if err != nil {
-			log.Printf("failed finding slot %v\n", err)
-			continue
+	if p.focusDuration == 0 {
+		return fmt.Errorf("failed to plan, focusDuration is 0")
+	}
+	var timeZone string
+	if p.recurrence != "" {
+		// each focus block is a series of its own, over a range they
+		// would repeat on top of each other
+		if len(p.days()) > 1 {
+			return fmt.Errorf("--repeat plans a single day, the focus time repeats by the rule on the other days")
+		}
+		var err error
+		if timeZone, err = localTimeZone(); err != nil {
+			return err
 		}
-		log.Printf("found a slot on %v\n", slot.Format(time.Kitchen))
-		p.events.insert(newFocusEvent(slot, p.focusDuration))
 	}
-	return nil
-}
+	if err := p.planMeetings(); err != nil {
+		return err
+	}
+	days := p.days()
+	planned := make([]time.Duration, len(days))
+	// a day is closed once there is no free slot left on it
+	closed := make([]bool, len(days))
+	p.scheduledFocusTime = 0
+	p.reusedFocusTime = 0
+	remaining := p.overallFocusTime
 
-func (p *Plan) commit() error {
-	if interactive {
-		var commit bool
-		err := survey.AskOne(&survey.Confirm{Message: "Commit changes to the calendar?", Default: true}, &commit)
+	// focus events created on previous plans are reused
+	for e := p.events.Front(); e != nil; e = e.Next() {
+		v := e.Value.(*calendar.Event)
+		if !isCalgoFocusEvent(v) {
+			continue
+		}
+		start, end, err := eventTimes(v)
 		if err != nil {
 			return err
 		}
-		if !commit {
-			return nil
+		for i, day := range days {
+			if sameDay(start, day) {
+				planned[i] += end.Sub(start)
+				remaining -= end.Sub(start)
+				p.reusedFocusTime += end.Sub(start)
+			}
 		}
 	}
-	for _, newEvent := range p.getAddedEvents() {
-		_, err := p.eventInserter(newEvent)
+	p.scheduledFocusTime = p.reusedFocusTime
+	if p.reusedFocusTime > 0 {
+		log.Printf("reusing %s of focus time calgo already planned\n", p.reusedFocusTime)
+	}
+
+	// blockSize is the longest focus event to plan next on a day
+	blockSize := func(i int) time.Duration {
+		size := p.focusDuration
+		if remaining < size {
+			size = remaining
+		}
+		if p.maxFocusPerDay > 0 && p.maxFocusPerDay-planned[i] < size {
+			size = p.maxFocusPerDay - planned[i]
+		}
+		return size
+	}
+	canPlan := func(i int) bool {
+		return !closed[i] && blockSize(i) >= p.minBlock()
+	}
+	planOn := func(i int) bool {
+		slot, err := p.findNextSlot(days[i], p.minBlock(), blockSize(i))
 		if err != nil {
-			log.Fatalf("Unable to create event. %v\n", err)
+			log.Printf("failed finding slot %v\n", err)
+			closed[i] = true
+			return false
+		}
+		log.Printf("found a slot on %v for %s\n", slot.StartTime.Format(time.Kitchen), slot.Duration())
+		focus := newFocusEvent(slot.StartTime, slot.Duration())
+		if p.recurrence != "" {
+			makeRecurring(focus, p.recurrence, timeZone)
 		}
+		p.events.insert(focus)
+		planned[i] += slot.Duration()
+		remaining -= slot.Duration()
+		p.scheduledFocusTime += slot.Duration()
+		return true
+	}
+
+	switch p.balance {
+	case balanceFront:
+		for i := range days {
+			for canPlan(i) && planOn(i) {
+			}
+		}
+	default:
+		// round-robin one focus event per day until nothing can be added
+		for progress := true; progress; {
+			progress = false
+			for i := range days {
+				if canPlan(i) && planOn(i) {
+					progress = true
+				}
+			}
+		}
+	}
+	if remaining > 0 {
+		log.Printf("could not plan %s out of %s focus time\n", remaining, p.overallFocusTime)
 	}
 	return nil
 }
 
+// proposedString lists the events the plan is about to add
+func (p *Plan) proposedString() string {
+	buf := bytes.NewBufferString("")
+	added := p.getAddedEvents()
+	fmt.Fprintf(buf, "%d proposed events:\n", len(added))
+	for _, e := range added {
+		start, _, _ := eventTimes(e)
+		fmt.Fprintf(buf, "%s %s", start.Format(dayFormat), eventString(e))
+	}
+	return buf.String()
+}
+
+// diffString shows the existing events of the plan days along with the
+// additions of the plan, colored
+func (p *Plan) diffString() string {
+	buf := bytes.NewBufferString("")
+	for _, day := range p.days() {
+		fmt.Fprintf(buf, "%s\n", pretty.Bold.Sprint(day.Format(dayFormat)))
+		for e := p.events.Front(); e != nil; e = e.Next() {
+			v := e.Value.(*calendar.Event)
+			start, _, err := eventTimes(v)
+			if err != nil || !sameDay(start, day) {
+				continue
+			}
+			if v.Id == "" {
+				fmt.Fprint(buf, pretty.FgGreen.Sprint(eventString(v)))
+				continue
+			}
+			fmt.Fprint(buf, pretty.Faint.Sprint(eventString(v)))
+		}
+	}
+	return buf.String()
+}
+
 func (p *Plan) String() string {
 	buf := bytes.NewBufferString("")
 	fmt.Fprintf(buf, "\n\nPlan for %v %d events\n", p.date.Format(time.RFC822), p.events.Len())
-	for e := p.events.Front(); e != nil; e = e.Next() {
-		v := e.Value.(*calendar.Event)
-		fmt.Fprintf(buf, eventString(v))
+	fmt.Fprintf(buf, "Scheduled %s out of %s focus time\n", p.scheduledFocusTime, p.overallFocusTime)
+	if p.reusedFocusTime > 0 {
+		fmt.Fprintf(buf, "%s of it was planned before\n", p.reusedFocusTime)
+	}
+	if len(p.replaced) > 0 {
+		fmt.Fprintf(buf, "Replacing %d events calgo planned before\n", len(p.replaced))
+	}
+	for _, day := range p.days() {
+		var dayEvents []*calendar.Event
+		var added int
+		var focus time.Duration
+		for e := p.events.Front(); e != nil; e = e.Next() {
+			v := e.Value.(*calendar.Event)
+			start, end, err := eventTimes(v)
+			if err != nil || !sameDay(start, day) {
+				continue
+			}
+			dayEvents = append(dayEvents, v)
+			if v.Id == "" {
+				added++
+				if calgoKind(v) == kindFocus {
+					focus += end.Sub(start)
+				}
+			}
+		}
+		fmt.Fprintf(buf, "\n%s: %d events, %d new, %s focus time\n", day.Format(dayFormat), len(dayEvents), added, focus)
+		for _, v := range dayEvents {
+			fmt.Fprint(buf, eventString(v))
+		}
 	}
 	return buf.String()
 }
 
 // planCmd represents the plan command
 var planCmd = &cobra.Command{
-	Use:   "plan",
-	Short: "Plan your day",
-	Long:  `Plan your day, add meetings, focus times, and break time.`,
-	Example: `$ calgo plan --focus-time 5h --tasks 1 --break 1
-# 
-dd/mm/yy, today, 0 meetings
-[focus time] duration(minutes) for each interval?(45): 50
-[focus time] optional event name?(focus time): create calgo
-✔ done
-
-[meeting 1] event name? discuss new requirements
-[meeting 1] duration?(50m):
-[meeting 1] attendants (tab to autocomplete, enter twice to done):
-rgo(tab) - rgolan@redhat.com
+	Use:   "plan [DAY EXPRESSION]/[RANGE EXPRESSION]",
+	Short: "Plan your day or week",
+	Long: `Plan your day, add meetings, focus times, and break time.
+When given a range the focus time is spread over the days of the range.
+Meetings are scheduled first, where all of their attendees are available.
+Unless --interactive=false, the focus interval and the meetings are asked for.`,
+	Example: `$ calgo plan --focus-time 5h --meetings 2h --break 1h
+? [focus time] duration for each interval? (45m) 50m
+? [focus time] optional event name? (Focus Time) create calgo
+? [meeting 1] event name? discuss new requirements
+? [meeting 1] duration? (50m)
+? [meeting 1] attendees (tab to autocomplete, enter on empty to finish): rgo(tab) - rgolan@redhat.com
+...
+✔ [meeting 1] scheduled to 14:00-14:50 as all attendees are available
+
+$ calgo plan --focus-time 3h --meeting "discuss new requirements;50m;rgolan@redhat.com" --interactive=false
+
+$ calgo plan m-f --focus-time 10h --max-focus-per-day 3h --balance front
+
+$ calgo plan m-f --focus-time 10h --output json > plan.json # commit later with calgo apply plan.json
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, tmax, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		// no point in planning days which already passed
+		if today := startOfDay(clock()); tmin.Before(today) {
+			tmin = today
+		}
+		focusSettings, err = loadFocusSettings()
+		if err != nil {
+			return err
+		}
+		aliases, err := readAliases()
+		if err != nil {
+			return err
+		}
+		meetings, err := parseMeetings(planMeetingFlags, aliases)
+		if err != nil {
+			return err
+		}
 		srv := google_calendar.Service()
 
-		//focuses := surveyFocus()
-		//meetings := surveyMeetings()
-		plan := newPlan(calendarID, srv)
-		err := plan.plan()
+		plan, err := newPlan(calendarID, srv, tmin, tmax)
 		if err != nil {
 			return err
 		}
-		log.Println(plan)
-		plan.commit()
-
-		return nil
+		plan.meetings = meetings
+		plan.recurrence, err = parseRepeat(repeat, repeatCount)
+		if err != nil {
+			return err
+		}
+		// the wizard asks for what the flags leave out, without a user
+		// to ask or with --interactive=false everything comes from the
+		// flags
+		if interactive && planOutput == outputText && stdinIsTerminal() {
+			askInterval := !cmd.Flags().Changed("max-focus-block") && !cmd.Flags().Changed("focus-event-duration")
+			askTitle := !cmd.Flags().Changed("focus-title")
+			if err := surveyFocus(plan, askInterval, askTitle); err != nil {
+				return err
+			}
+			if meetingsTime > 0 {
+				if err := surveyMeetings(plan, meetingsTime, loadAddressBook(srv)); err != nil {
+					return err
+				}
+			}
+		}
+		err = plan.plan()
+		if err != nil {
+			return err
+		}
+		switch {
+		case planOutput == outputJSON:
+			// the plan file is for committing later with calgo apply
+			return printJSON(os.Stdout, plan.file())
+		case showDiff:
+			fmt.Print(plan.diffString())
+		default:
+			log.Println(plan)
+		}
+		if dryRun {
+			fmt.Print(plan.proposedString())
+			return nil
+		}
+		results, err := plan.commit()
+		fmt.Print(results)
+		return err
 	},
 	Args: func(cmd *cobra.Command, args []string) error {
-		if focusTime < focusEventDuration {
-			return fmt.Errorf("--focus-time (%s) must be greater then or equal to --focus-event-duration (%s)", focusTime, focusEventDuration)
+		if err := validateDateExpressionArgs(args); err != nil {
+			return err
+		}
+		if err := validateOutputFormat(planOutput); err != nil {
+			return err
 		}
-		return nil
+		if balance != balanceEven && balance != balanceFront {
+			return fmt.Errorf("--balance must be either %s or %s", balanceEven, balanceFront)
+		}
+		if _, err := getSlotStrategy(strategy); err != nil {
+			return err
+		}
+		if focusEventDuration <= 0 {
+			return fmt.Errorf("--max-focus-block must be greater than 0")
+		}
+		if minFocusBlock <= 0 || minFocusBlock > focusEventDuration {
+			return fmt.Errorf("--min-focus-block (%s) must be greater than 0 and less than or equal to --max-focus-block (%s)", minFocusBlock, focusEventDuration)
+		}
+		if meetingsTime < 0 {
+			return fmt.Errorf("--meetings must not be negative")
+		}
+		_, err := parseRepeat(repeat, repeatCount)
+		return err
 	},
 }
 
-func surveyFocus() []Focus {
-	focuses := []Focus{}
-	var duration = time.Minute * 45
-	var title = "Focus Time"
-	// prevent 0, make sure = < focusTime
-	survey.AskOne(&survey.Input{Message: "duration(minutes) for each focus slot?", Default: "45m"}, &duration)
-	survey.AskOne(&survey.Input{Message: "focus time title?", Default: "Focus Time"}, &title)
-
-	var nFocuses = int(focusTime / duration)
-	for i := 0; i < nFocuses; i++ {
-		focuses = append(focuses, Focus{
-			Duration: duration,
-			Title:    title,
-		})
-	}
-
-	return focuses
-}
-
-func surveyMeetings() []Meeting {
-	if meetingsTime == 0 {
-		return nil
-	}
-	meetings := []Meeting{}
-	var duration = time.Minute * 45
-	var title = ""
-	// prevent 0, make sure = < focusTime
-	survey.AskOne(&survey.Input{Message: "duration in minutes for each meeting?", Default: "45m"}, &duration)
-	survey.AskOne(&survey.Input{Message: "meeting title?"}, &title)
-
-	var nFocuses = int(meetingsTime / duration)
-	for i := 0; i < nFocuses; i++ {
-		meetings = append(meetings, Meeting{
-			Duration: duration,
-			Title:    title,
-		})
-	}
-
-	return meetings
+// addCommitFlags adds the flags controlling how events are sent to the
+// calendar
+func addCommitFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&commitWorkers, "workers", 4, "number of events to create concurrently")
+	cmd.Flags().Float64Var(&commitRate, "rate", 5, "maximum number of requests per second, 0 for no limit")
 }
 
 func newFocusEvent(startTime time.Time, duration time.Duration) *calendar.Event {
-	return &calendar.Event{
-		Summary:     "Focus Time",
-		Description: "Focus Time",
+	return stampKind(&calendar.Event{
+		Summary:     focusSettings.Title,
+		Description: focusSettings.Title,
 		EventType:   "focusTime",
+		FocusTimeProperties: &calendar.EventFocusTimeProperties{
+			AutoDeclineMode: autoDeclineModes[focusSettings.AutoDecline],
+			DeclineMessage:  focusSettings.DeclineMessage,
+			ChatStatus:      chatStatuses[focusSettings.ChatStatus],
+		},
 		Start: &calendar.EventDateTime{
 			DateTime: startTime.Format(time.RFC3339),
 		},
 		End: &calendar.EventDateTime{
 			DateTime: startTime.Add(duration).Format(time.RFC3339),
 		},
-		//Attendees: []*calendar.EventAttendee{
-		//	&calendar.EventAttendee{Email:"lpage@example.com"},
-		//	&calendar.EventAttendee{Email:"sbrin@example.com"},
-		//},
-	}
+	}, kindFocus)
 }
 
 func (p *Plan) getAddedEvents() []*calendar.Event {
@@ -231,63 +529,98 @@ func (p *Plan) getAddedEvents() []*calendar.Event {
 	return events
 }
 
-// find the next slot to add an event
-func (p *Plan) findNextSlot() (time.Time, error) {
+// freeSlots returns the free periods of the given day, sorted by time
+func (p *Plan) freeSlots(day time.Time) ([]Slot, error) {
 	// walk the list of events, which are sorted by start time, measure
-	// a free period of the wanted duration by starting a point in time, now for
-	// today, or start of day if in future.
-	// measure from that markpoint to next meeting start date , test if it can
-	// contain the period.
-	// TODO handle overlapping meetings to calculate the available periods.
-	// currently naively assuming there is no overlap.
+	// the free periods by starting a point in time, now for today, or
+	// start of day if in future.
+	// the period from that markpoint to next meeting start date is free.
+	// events ending before the markpoint, e.g overlapping meetings or
+	// meetings of other days, are skipped.
 	// advanced the markpoint to the end of current meeting and start again
 
-	var markpoint time.Time
-	y, m, d := p.date.Date()
-	y1, m1, d1 := time.Now().Date()
-	if y == y1 && m == m1 && d == d1 {
-		s := startOfDay(time.Now())
-		// it maybe that the day already started and we want to plan. if now
-		// is later the startofday then use it.
-		if time.Now().After(s) {
-			markpoint = time.Now()
-		} else {
-			markpoint = s
-		}
-	} else {
-		markpoint = startOfDay(p.date)
+	markpoint := startOfDay(day)
+	dayEnd := endOfDay(day)
+	// it maybe that the day already started and we want to plan. if now
+	// is later the startofday then use it.
+	if now := p.currentTime(); sameDay(day, now) && now.After(markpoint) {
+		markpoint = now
 	}
+	var free []Slot
 	for elm := p.events.Front(); elm != nil; elm = elm.Next() {
 		nextEvent := elm.Value.(*calendar.Event)
-		nextEventStartTime, err := time.Parse(time.RFC3339, nextEvent.Start.DateTime)
+		if !isBusy(nextEvent) {
+			continue
+		}
+		nextEventStartTime, nextEventEndTime, err := eventTimes(nextEvent)
 		if err != nil {
-			return markpoint, err
+			return nil, err
 		}
-		if markpoint.Add(p.focusDuration).Before(nextEventStartTime) {
-			return markpoint, nil
+		if !nextEventEndTime.After(markpoint) || !nextEventStartTime.Before(dayEnd) {
+			continue
 		}
-
-		nextEventEndTime, err := time.Parse(time.RFC3339, nextEvent.End.DateTime)
-		if err != nil {
-			return markpoint, err
+		if nextEventStartTime.After(markpoint) {
+			free = append(free, Slot{StartTime: markpoint, EndTime: nextEventStartTime})
 		}
 		markpoint = nextEventEndTime
-		if elm.Next() == nil {
-			// last element
-			if markpoint.Add(p.focusDuration).Before(endOfDay(p.date)) {
-				return markpoint, nil
-			}
-		}
 	}
-	log.Println("couldn't find a slot")
-	return markpoint, fmt.Errorf("couldn't not find a slot")
+	if markpoint.Before(dayEnd) {
+		free = append(free, Slot{StartTime: markpoint, EndTime: dayEnd})
+	}
+	return free, nil
+}
+
+// find the next slot to add an event of min to max duration on the given
+// day, the slot is chosen by the strategy of the plan
+func (p *Plan) findNextSlot(day time.Time, min, max time.Duration) (Slot, error) {
+	strategy, err := getSlotStrategy(p.strategyName())
+	if err != nil {
+		return Slot{}, err
+	}
+	free, err := p.freeSlots(day)
+	if err != nil {
+		return Slot{}, err
+	}
+	slot, ok := strategy(free, min, max)
+	if !ok {
+		return slot, fmt.Errorf("couldn't find a slot on %s", day.Format(dayFormat))
+	}
+	return slot, nil
+}
+
+func (p *Plan) strategyName() string {
+	if p.strategy == "" {
+		return defaultStrategy
+	}
+	return p.strategy
 }
 
 func init() {
 	planCmd.Flags().BoolVar(&interactive, "interactive", true, "Ask before committing changes, ask optional inputs")
 	planCmd.Flags().DurationVar(&focusTime, "focus-time", time.Minute*45, "desired overall focus time duration (e.g 45m, 1h20m)")
-	planCmd.Flags().DurationVar(&focusEventDuration, "focus-event-duration", time.Minute*45, "desired focus time per event. An overall focus time is devided to events (e.g 45m, 1h20m)")
-	planCmd.Flags().DurationVar(&meetingsTime, "meetings", 0, "desired meetings overall time duration (e.g 1h30m")
+	planCmd.Flags().DurationVar(&focusEventDuration, "max-focus-block", time.Minute*45, "longest focus time event. An overall focus time is devided to events (e.g 45m, 1h20m)")
+	planCmd.Flags().DurationVar(&focusEventDuration, "focus-event-duration", time.Minute*45, "desired focus time per event")
+	planCmd.Flags().MarkDeprecated("focus-event-duration", "use --max-focus-block instead")
+	planCmd.Flags().DurationVar(&minFocusBlock, "min-focus-block", time.Minute*30, "shortest focus time event, free slots shorter than that are not used (e.g 25m)")
+	planCmd.Flags().DurationVar(&maxFocusPerDay, "max-focus-per-day", 0, "maximum focus time to plan on a single day, 0 for no limit (e.g 3h)")
+	planCmd.Flags().StringVar(&balance, "balance", balanceEven, "how to spread the focus time over a range of days, either 'even' or 'front' to fill up the first days")
+	planCmd.Flags().StringVar(&strategy, "strategy", defaultStrategy, fmt.Sprintf("how to pick a free slot for focus time, one of %s", strategyNames()))
+	planCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the proposed events without committing them")
+	planCmd.Flags().BoolVar(&showDiff, "diff", false, "show the existing events along with the [+] additions of the plan")
+	addOutputFlag(planCmd, &planOutput)
+	addCommitFlags(planCmd)
+	planCmd.Flags().String("focus-title", "", "title of the focus events (default \"Focus Time\")")
+	planCmd.Flags().String("auto-decline", "", fmt.Sprintf("auto decline invitations conflicting with focus time, one of %s (default \"none\")", keys(autoDeclineModes)))
+	planCmd.Flags().String("decline-message", "", "message to send with the auto declined invitations")
+	planCmd.Flags().String("chat-status", "", fmt.Sprintf("chat status while in focus time, one of %s (default \"available\")", keys(chatStatuses)))
+	viper.BindPFlag("focus.title", planCmd.Flags().Lookup("focus-title"))
+	viper.BindPFlag("focus.autoDecline", planCmd.Flags().Lookup("auto-decline"))
+	viper.BindPFlag("focus.declineMessage", planCmd.Flags().Lookup("decline-message"))
+	viper.BindPFlag("focus.chatStatus", planCmd.Flags().Lookup("chat-status"))
+	addRepeatFlags(planCmd)
+	planCmd.Flags().BoolVar(&replaceFocus, "replace", false, "replace the focus events and meetings calgo planned before on these days, instead of reusing them")
+	planCmd.Flags().DurationVar(&meetingsTime, "meetings", 0, "desired meetings overall time duration, the meetings are asked for interactively (e.g 1h30m)")
+	planCmd.Flags().StringArrayVar(&planMeetingFlags, "meeting", nil, "a meeting to schedule where all attendees are available, 'TITLE;DURATION;EMAIL,ALIAS', repeat for several")
 	planCmd.Flags().DurationVar(&tasks, "break", time.Hour, "desired break time duration (e.g 1h)")
 	rootCmd.AddCommand(planCmd)
 }
@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"container/list"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/api/calendar/v3"
 	"testing"
@@ -39,6 +40,16 @@ var testCases = []testCase{
 				Summary:     "Focus Time",
 				Description: "Focus Time",
 				EventType:   "focusTime",
+				FocusTimeProperties: &calendar.EventFocusTimeProperties{
+					AutoDeclineMode: "declineNone",
+					ChatStatus:      "available",
+				},
+				ExtendedProperties: &calendar.EventExtendedProperties{
+					Private: map[string]string{
+						calgoKindProperty:    kindFocus,
+						calgoVersionProperty: calgoVersion,
+					},
+				},
 				Start: &calendar.EventDateTime{
 					DateTime: time.Date(2023, 9, 24, 8, 50, 0, 0, time.UTC).Format(time.RFC3339),
 				},
@@ -68,7 +79,7 @@ func TestPlan(t *testing.T) {
 		}
 
 		p := &Plan{
-			date:             time.Now(),
+			date:             time.Date(2023, 9, 24, 0, 0, 0, 0, time.UTC),
 			overallFocusTime: tc.focusTime,
 			focusDuration:    tc.focusDuration,
 			eventInserter:    eventInserter,
@@ -80,3 +91,208 @@ func TestPlan(t *testing.T) {
 		assert.Equal(t, tc.wantedEvents, p.getAddedEvents())
 	}
 }
+
+func TestPlanRange(t *testing.T) {
+	monday := time.Date(2023, 9, 25, 0, 0, 0, 0, time.UTC)
+	meeting := &calendar.Event{
+		Id: "1",
+		Start: &calendar.EventDateTime{
+			DateTime: time.Date(2023, 9, 25, 8, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		},
+		End: &calendar.EventDateTime{
+			DateTime: time.Date(2023, 9, 25, 9, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		},
+	}
+
+	cases := []struct {
+		name           string
+		balance        string
+		maxFocusPerDay time.Duration
+		wantedEvents   []*calendar.Event
+	}{
+		{
+			name:    "even",
+			balance: balanceEven,
+			wantedEvents: []*calendar.Event{
+				newFocusEvent(time.Date(2023, 9, 25, 9, 0, 0, 0, time.UTC), time.Hour),
+				newFocusEvent(time.Date(2023, 9, 25, 10, 0, 0, 0, time.UTC), time.Hour),
+				newFocusEvent(time.Date(2023, 9, 26, 8, 0, 0, 0, time.UTC), time.Hour),
+				newFocusEvent(time.Date(2023, 9, 27, 8, 0, 0, 0, time.UTC), time.Hour),
+			},
+		},
+		{
+			name:           "front with a cap",
+			balance:        balanceFront,
+			maxFocusPerDay: 3 * time.Hour,
+			wantedEvents: []*calendar.Event{
+				newFocusEvent(time.Date(2023, 9, 25, 9, 0, 0, 0, time.UTC), time.Hour),
+				newFocusEvent(time.Date(2023, 9, 25, 10, 0, 0, 0, time.UTC), time.Hour),
+				newFocusEvent(time.Date(2023, 9, 25, 11, 0, 0, 0, time.UTC), time.Hour),
+				newFocusEvent(time.Date(2023, 9, 26, 8, 0, 0, 0, time.UTC), time.Hour),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			events := newEvents()
+			events.addAll([]*calendar.Event{meeting})
+			p := &Plan{
+				date:             monday,
+				endDate:          endOfDay(monday.AddDate(0, 0, 2)),
+				overallFocusTime: 4 * time.Hour,
+				focusDuration:    time.Hour,
+				maxFocusPerDay:   tc.maxFocusPerDay,
+				balance:          tc.balance,
+				events:           events,
+			}
+
+			err := p.plan()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantedEvents, p.getAddedEvents())
+		})
+	}
+}
+
+// existingMeetings creates persisted events from pairs of start and end times
+func existingMeetings(times ...[2]time.Time) []*calendar.Event {
+	events := []*calendar.Event{}
+	for i, t := range times {
+		events = append(events, &calendar.Event{
+			Id:      fmt.Sprint(i + 1),
+			Summary: fmt.Sprintf("meeting %d", i+1),
+			Start:   &calendar.EventDateTime{DateTime: t[0].Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: t[1].Format(time.RFC3339)},
+		})
+	}
+	return events
+}
+
+func TestPlanWithStrategy(t *testing.T) {
+	events := newEvents()
+	events.addAll(existingMeetings([2]time.Time{at(9, 0), at(11, 0)}))
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: time.Hour,
+		focusDuration:    time.Hour,
+		strategy:         "latest",
+		events:           events,
+	}
+
+	err := p.plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []*calendar.Event{newFocusEvent(at(19, 0), time.Hour)}, p.getAddedEvents())
+}
+
+func TestPlanVariableBlocks(t *testing.T) {
+	events := newEvents()
+	// free: 08:00-10:00, 10:30-11:10, 11:30-11:45, 12:00-20:00
+	events.addAll(existingMeetings(
+		[2]time.Time{at(10, 0), at(10, 30)},
+		[2]time.Time{at(11, 10), at(11, 30)},
+		[2]time.Time{at(11, 45), at(12, 0)},
+	))
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: 3*time.Hour + 10*time.Minute,
+		focusDuration:    2 * time.Hour,
+		minFocusDuration: 30 * time.Minute,
+		events:           events,
+	}
+
+	err := p.plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []*calendar.Event{
+		newFocusEvent(at(8, 0), 2*time.Hour),
+		newFocusEvent(at(10, 30), 40*time.Minute),
+		newFocusEvent(at(12, 0), 30*time.Minute),
+	}, p.getAddedEvents())
+	assert.Equal(t, p.overallFocusTime, p.scheduledFocusTime)
+}
+
+func TestPlanReportsUnscheduledFocusTime(t *testing.T) {
+	events := newEvents()
+	events.addAll(existingMeetings([2]time.Time{at(8, 0), at(19, 0)}))
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: 5 * time.Hour,
+		focusDuration:    45 * time.Minute,
+		minFocusDuration: 30 * time.Minute,
+		events:           events,
+	}
+
+	err := p.plan()
+	assert.NoError(t, err)
+	assert.Equal(t, 45*time.Minute, p.scheduledFocusTime)
+	assert.Contains(t, p.String(), "Scheduled 45m0s out of 5h0m0s focus time")
+}
+
+func TestPlanWithClock(t *testing.T) {
+	cases := []struct {
+		name         string
+		now          time.Time
+		wantedEvents []*calendar.Event
+	}{
+		{
+			name:         "before the day starts",
+			now:          at(6, 30),
+			wantedEvents: []*calendar.Event{newFocusEvent(at(8, 0), time.Hour)},
+		},
+		{
+			name:         "in the middle of the day",
+			now:          at(10, 17),
+			wantedEvents: []*calendar.Event{newFocusEvent(at(10, 17), time.Hour)},
+		},
+		{
+			name:         "too late to plan",
+			now:          at(19, 50),
+			wantedEvents: []*calendar.Event{},
+		},
+		{
+			name:         "planning tomorrow",
+			now:          at(19, 50).AddDate(0, 0, -1),
+			wantedEvents: []*calendar.Event{newFocusEvent(at(8, 0), time.Hour)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Plan{
+				now:              fixedClock(tc.now),
+				date:             at(0, 0),
+				overallFocusTime: time.Hour,
+				focusDuration:    time.Hour,
+				events:           newEvents(),
+			}
+
+			err := p.plan()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantedEvents, p.getAddedEvents())
+		})
+	}
+}
+
+func TestPlanOverDSTChange(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no timezone data: %v", err)
+	}
+	// clocks go forward on the night of Sunday 26 March 2023
+	saturday := time.Date(2023, 3, 25, 0, 0, 0, 0, berlin)
+	p := &Plan{
+		now:              fixedClock(time.Date(2023, 3, 24, 12, 0, 0, 0, berlin)),
+		date:             saturday,
+		endDate:          endOfDay(saturday.AddDate(0, 0, 2)),
+		overallFocusTime: 3 * time.Hour,
+		focusDuration:    time.Hour,
+		events:           newEvents(),
+	}
+
+	err = p.plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []*calendar.Event{
+		newFocusEvent(time.Date(2023, 3, 25, 8, 0, 0, 0, berlin), time.Hour),
+		newFocusEvent(time.Date(2023, 3, 26, 8, 0, 0, 0, berlin), time.Hour),
+		newFocusEvent(time.Date(2023, 3, 27, 8, 0, 0, 0, berlin), time.Hour),
+	}, p.getAddedEvents())
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"sync"
+	"time"
+)
+
+// newRateLimiter returns a wait func which blocks so calls to it don't exceed
+// the rate per second. The first call doesn't wait, a token is ready up
+// front. A rate of zero or less is not limited.
+func newRateLimiter(rate float64) (wait func(), stop func()) {
+	if rate <= 0 {
+		return func() {}, func() {}
+	}
+	tokens := make(chan struct{}, 1)
+	tokens <- struct{}{}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				select {
+				case tokens <- struct{}{}:
+				default:
+					// the token of the previous tick was not taken yet
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { <-tokens }, func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// runPool calls the task with the indexes 0 to n-1 on a bounded number of
+// workers. Tasks are started in the order of their index, and each task
+// should keep its result by its index so the reporting is in order no matter
+// which task finished first. The task calls wait before each request it
+// sends, retries included, so all the requests together don't exceed rate
+// per second.
+func runPool(n, workers int, rate float64, task func(i int, wait func())) {
+	if workers < 1 {
+		workers = 1
+	}
+	wait, stop := newRateLimiter(rate)
+	defer stop()
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				task(i, wait)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// calendarStandIn is a local stand-in for the calendar API events endpoint
+type calendarStandIn struct {
+	mu       sync.Mutex
+	inFlight int32
+	// maxInFlight is the most requests handled at the same time
+	maxInFlight int32
+	deleted     []string
+}
+
+func (c *calendarStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := atomic.AddInt32(&c.inFlight, 1)
+	defer atomic.AddInt32(&c.inFlight, -1)
+	c.mu.Lock()
+	if n > c.maxInFlight {
+		c.maxInFlight = n
+	}
+	c.mu.Unlock()
+	// give other requests the chance to run concurrently
+	time.Sleep(10 * time.Millisecond)
+
+	switch r.Method {
+	case http.MethodPost:
+		e := &calendar.Event{}
+		_ = json.NewDecoder(r.Body).Decode(e)
+		if e.Summary == "fail" {
+			http.Error(w, `{"error": {"code": 400, "message": "bad event"}}`, http.StatusBadRequest)
+			return
+		}
+		e.Id = "id-" + strings.ReplaceAll(e.Summary, " ", "-")
+		_ = json.NewEncoder(w).Encode(e)
+	case http.MethodDelete:
+		c.mu.Lock()
+		c.deleted = append(c.deleted, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		c.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func standInPlan(t *testing.T, standIn *calendarStandIn, summaries ...string) *Plan {
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	srv, err := calendar.NewService(context.Background(),
+		option.WithEndpoint(server.URL+"/"),
+		option.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+
+	events := newEvents()
+	for i, summary := range summaries {
+		e := newFocusEvent(at(8, 10*i), 10*time.Minute)
+		e.Summary = summary
+		events.insert(e)
+	}
+	return &Plan{
+		date: at(0, 0),
+		eventInserter: func(event *calendar.Event) (*calendar.Event, error) {
+			return srv.Events.Insert("primary", event).Do()
+		},
+		eventDeleter: func(id string) error {
+			return srv.Events.Delete("primary", id).Do()
+		},
+		workers: 3,
+		events:  events,
+	}
+}
+
+func TestRunPool(t *testing.T) {
+	results := make([]int, 20)
+	runPool(len(results), 4, 0, func(i int, wait func()) {
+		wait()
+		results[i] = i * i
+	})
+	for i, r := range results {
+		assert.Equal(t, i*i, r)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	wait, stop := newRateLimiter(10)
+	defer stop()
+
+	start := time.Now()
+	wait()
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "the first call doesn't wait")
+	wait()
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestCommitRetriesAreRateLimited(t *testing.T) {
+	nonInteractiveCommit(t)
+	f := &fakeCalendar{failures: map[int]error{1: &googleapi.Error{Code: http.StatusTooManyRequests}}}
+	p := planWithFocusEvents(f, 1)
+	p.rate = 10
+
+	start := time.Now()
+	_, err := p.commit()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, f.calls)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond, "the retry waits for its turn")
+}
+
+func TestConcurrentCommit(t *testing.T) {
+	nonInteractiveCommit(t)
+	standIn := &calendarStandIn{}
+	p := standInPlan(t, standIn, "a", "b", "c", "d", "e", "f")
+	added := p.getAddedEvents()
+
+	_, err := p.commit()
+	assert.NoError(t, err)
+	for _, e := range added {
+		assert.Equal(t, "id-"+e.Summary, e.Id)
+	}
+	assert.LessOrEqual(t, standIn.maxInFlight, int32(3))
+	assert.Greater(t, standIn.maxInFlight, int32(1))
+}
+
+func TestConcurrentCommitRollsBack(t *testing.T) {
+	nonInteractiveCommit(t)
+	standIn := &calendarStandIn{}
+	p := standInPlan(t, standIn, "a", "b", "fail", "d", "e", "f")
+
+	_, err := p.commit()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"fail"`)
+	assert.Len(t, p.getAddedEvents(), 6, "created events are rolled back")
+	assert.Contains(t, standIn.deleted, "id-a")
+	assert.Contains(t, standIn.deleted, "id-b")
+}
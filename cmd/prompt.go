@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"golang.org/x/term"
+)
+
+// askOne asks a single question, it is replaced in tests
+var askOne = survey.AskOne
+
+// stdinIsTerminal tells if there is a user to ask, rather than a script
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// confirm asks a yes/no question
+func confirm(message string, defaultAnswer bool) (bool, error) {
+	var answer bool
+	err := askOne(&survey.Confirm{Message: message, Default: defaultAnswer}, &answer)
+	return answer, err
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	repeat      string
+	repeatCount int
+)
+
+// repeatDays maps day names of a --repeat rule to RRULE days. The day
+// expressions letters are accepted as well as two letter names.
+var repeatDays = map[string]string{
+	"s": "SU", "su": "SU",
+	"m": "MO", "mo": "MO",
+	"t": "TU", "tu": "TU",
+	"w": "WE", "we": "WE",
+	"th": "TH",
+	"f":  "FR", "fr": "FR",
+	"sa": "SA",
+}
+
+var repeatFrequencies = map[string]string{
+	"daily":    "DAILY",
+	"weekdays": "WEEKLY",
+	"weekly":   "WEEKLY",
+	"monthly":  "MONTHLY",
+}
+
+// parseRepeat turns a repeat rule to an RRULE, an empty rule is no
+// recurrence. A rule is a frequency, one of
+// daily, weekdays, weekly or monthly, and for weekly optionally the days
+// of the week, e.g weekly:mo,we. A count greater than zero ends the
+// recurrence after that many events.
+func parseRepeat(rule string, count int) (string, error) {
+	if count < 0 {
+		return "", fmt.Errorf("--repeat-count must not be negative")
+	}
+	if rule == "" {
+		if count > 0 {
+			return "", fmt.Errorf("--repeat-count is set without --repeat")
+		}
+		return "", nil
+	}
+	frequency, days, hasDays := strings.Cut(strings.ToLower(rule), ":")
+	freq, ok := repeatFrequencies[frequency]
+	if !ok {
+		return "", fmt.Errorf("unsupported repeat %q, use one of daily, weekdays, weekly, weekly:mo,we or monthly", rule)
+	}
+	rrule := "RRULE:FREQ=" + freq
+	if frequency == "weekdays" {
+		rrule += ";BYDAY=MO,TU,WE,TH,FR"
+	}
+	if hasDays {
+		if frequency != "weekly" {
+			return "", fmt.Errorf("days can only be set for a weekly repeat, got %q", rule)
+		}
+		var byDay []string
+		for _, d := range strings.Split(days, ",") {
+			day, ok := repeatDays[strings.TrimSpace(d)]
+			if !ok {
+				return "", fmt.Errorf("unsupported day %q in repeat %q", d, rule)
+			}
+			byDay = append(byDay, day)
+		}
+		rrule += ";BYDAY=" + strings.Join(byDay, ",")
+	}
+	if count > 0 {
+		rrule += fmt.Sprintf(";COUNT=%d", count)
+	}
+	return rrule, nil
+}
+
+// makeRecurring sets the recurrence of an event. Recurring events must
+// have the time zone of their start and end, see localTimeZone.
+func makeRecurring(e *calendar.Event, rrule, timeZone string) {
+	e.Recurrence = []string{rrule}
+	e.Start.TimeZone = timeZone
+	e.End.TimeZone = timeZone
+}
+
+// localTimeZone returns the IANA name of the local time zone, out of TZ or
+// the /etc/localtime link
+func localTimeZone() (string, error) {
+	if tz := os.Getenv("TZ"); tz != "" {
+		return strings.TrimPrefix(tz, ":"), nil
+	}
+	if link, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(link, "zoneinfo/"); ok {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unable to tell the local time zone of the recurring events, set TZ (e.g TZ=Europe/Berlin)")
+}
+
+func isRecurring(e *calendar.Event) bool {
+	return e.RecurringEventId != "" || len(e.Recurrence) > 0
+}
+
+func addRepeatFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&repeat, "repeat", "", "make the created events recurring, one of daily, weekdays, weekly, weekly:mo,we or monthly")
+	cmd.Flags().IntVar(&repeatCount, "repeat-count", 0, "end the recurrence after that many events, 0 to repeat forever")
+}
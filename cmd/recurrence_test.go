@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRepeat(t *testing.T) {
+	cases := []struct {
+		rule    string
+		count   int
+		wanted  string
+		wantErr bool
+	}{
+		{rule: "", wanted: ""},
+		{rule: "daily", wanted: "RRULE:FREQ=DAILY"},
+		{rule: "weekdays", wanted: "RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{rule: "weekly", count: 4, wanted: "RRULE:FREQ=WEEKLY;COUNT=4"},
+		{rule: "weekly:mo,we", wanted: "RRULE:FREQ=WEEKLY;BYDAY=MO,WE"},
+		{rule: "Weekly:m,th", wanted: "RRULE:FREQ=WEEKLY;BYDAY=MO,TH"},
+		{rule: "monthly", wanted: "RRULE:FREQ=MONTHLY"},
+		{rule: "yearly", wantErr: true},
+		{rule: "daily:mo", wantErr: true},
+		{rule: "weekly:xx", wantErr: true},
+		{rule: "", count: 3, wantErr: true},
+		{rule: "daily", count: -1, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.rule, func(t *testing.T) {
+			got, err := parseRepeat(tc.rule, tc.count)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wanted, got)
+		})
+	}
+}
+
+func TestPlanRecurringFocus(t *testing.T) {
+	t.Setenv("TZ", "Europe/Berlin")
+	p := &Plan{
+		date:             at(0, 0),
+		overallFocusTime: time.Hour,
+		focusDuration:    time.Hour,
+		recurrence:       "RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+		events:           newEvents(),
+	}
+
+	err := p.plan()
+	assert.NoError(t, err)
+	added := p.getAddedEvents()
+	assert.Len(t, added, 1)
+	assert.Equal(t, []string{"RRULE:FREQ=WEEKLY;BYDAY=MO,WE"}, added[0].Recurrence)
+	assert.Equal(t, "Europe/Berlin", added[0].Start.TimeZone)
+	assert.Equal(t, "Europe/Berlin", added[0].End.TimeZone)
+}
+
+func TestLocalTimeZone(t *testing.T) {
+	t.Setenv("TZ", ":America/New_York")
+	tz, err := localTimeZone()
+	assert.NoError(t, err)
+	assert.Equal(t, "America/New_York", tz)
+}
+
+func TestPlanRecurringFocusOverRange(t *testing.T) {
+	t.Setenv("TZ", "Europe/Berlin")
+	p := &Plan{
+		date:             at(0, 0),
+		endDate:          at(0, 0).AddDate(0, 0, 4),
+		overallFocusTime: 5 * time.Hour,
+		focusDuration:    time.Hour,
+		recurrence:       "RRULE:FREQ=WEEKLY",
+		events:           newEvents(),
+	}
+
+	err := p.plan()
+	assert.EqualError(t, err, "--repeat plans a single day, the focus time repeats by the rule on the other days")
+	assert.Empty(t, p.getAddedEvents())
+}
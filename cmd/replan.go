@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// move of a calgo event out of a conflict. When no free slot fits the whole
+// event, it is moved to the first slot and the rest of its time is split to
+// new focus events.
+type move struct {
+	event *calendar.Event
+	from  Slot
+	to    Slot
+	// split are the slots of the new focus events, for the rest of the time
+	split []Slot
+	// missing is the focus time no free slot was found for
+	missing time.Duration
+	err     error
+}
+
+type moves []*move
+
+func (m moves) String() string {
+	buf := bytes.NewBufferString("")
+	slotString := func(s Slot) string {
+		return fmt.Sprintf("%s %s - %s", s.StartTime.Format(dayFormat), s.StartTime.Format(time.Kitchen), s.EndTime.Format(time.Kitchen))
+	}
+	for _, mv := range m {
+		from := slotString(mv.from)
+		if mv.err != nil {
+			fmt.Fprintf(buf, "%-8s %s %s: %v\n", "stuck", from, mv.event.Summary, mv.err)
+			continue
+		}
+		fmt.Fprintf(buf, "%-8s %s -> %s %s\n", "moved", from, slotString(mv.to), mv.event.Summary)
+		for _, s := range mv.split {
+			fmt.Fprintf(buf, "%-8s %s -> %s %s\n", "split", from, slotString(s), mv.event.Summary)
+		}
+		if mv.missing > 0 {
+			fmt.Fprintf(buf, "%-8s %s %s: no free slot for %s of it\n", "short", from, mv.event.Summary, mv.missing)
+		}
+	}
+	return buf.String()
+}
+
+// conflictingFocusEvents returns the calgo focus events that overlap with
+// busy events other than calgo focus events, the meetings calgo planned
+// included
+func conflictingFocusEvents(events []*calendar.Event) ([]*calendar.Event, error) {
+	var conflicts []*calendar.Event
+	for _, focus := range events {
+		if !isCalgoFocusEvent(focus) {
+			continue
+		}
+		focusStart, focusEnd, err := eventTimes(focus)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range events {
+			if calgoKind(other) == kindFocus || !isBusy(other) {
+				continue
+			}
+			start, end, err := eventTimes(other)
+			if err != nil {
+				return nil, err
+			}
+			if start.Before(focusEnd) && end.After(focusStart) {
+				conflicts = append(conflicts, focus)
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// replan finds new slots for the conflicting events, on the day of the event
+// or on the following days of the plan. An event which doesn't fit a single
+// slot is split, down to the minimal focus block, so the total focus time is
+// kept. The plan events must not contain the conflicting events. The moved
+// and split events are added to the plan events.
+func (p *Plan) replan(conflicts []*calendar.Event) moves {
+	var result moves
+	for _, e := range conflicts {
+		start, end, err := eventTimes(e)
+		mv := &move{event: e, from: Slot{StartTime: start, EndTime: end}, err: err}
+		result = append(result, mv)
+		if err != nil {
+			continue
+		}
+		remaining := end.Sub(start)
+		minBlock := p.minBlock()
+		if minBlock <= 0 || minBlock > remaining {
+			minBlock = remaining
+		}
+		var slots []Slot
+		for _, day := range p.days() {
+			if midnight(day).Before(midnight(start)) {
+				continue
+			}
+			for remaining > 0 {
+				slot, err := p.findNextSlot(day, minDuration(minBlock, remaining), remaining)
+				if err != nil {
+					break
+				}
+				slots = append(slots, slot)
+				remaining -= slot.Duration()
+				p.events.insert(&calendar.Event{
+					Summary: e.Summary,
+					Start:   &calendar.EventDateTime{DateTime: slot.StartTime.Format(time.RFC3339)},
+					End:     &calendar.EventDateTime{DateTime: slot.EndTime.Format(time.RFC3339)},
+				})
+			}
+		}
+		if len(slots) == 0 {
+			mv.err = fmt.Errorf("no free slot of %s", minBlock)
+			continue
+		}
+		mv.to, mv.split, mv.missing = slots[0], slots[1:], remaining
+	}
+	return result
+}
+
+// splitEvent is a new focus event for a part of the time of a moved event,
+// of the same plan
+func splitEvent(e *calendar.Event, slot Slot) *calendar.Event {
+	split := newFocusEvent(slot.StartTime, slot.Duration())
+	split.Summary, split.Description = e.Summary, e.Description
+	if isCalgoEvent(e) {
+		stampPlan(split, e.ExtendedProperties.Private[calgoPlanIdProperty])
+	}
+	return split
+}
+
+// applyMoves patches the moved events with their new times, and inserts the
+// events split out of them
+func applyMoves(result moves, eventPatcher func(id string, patch *calendar.Event) error,
+	eventInserter func(event *calendar.Event) (*calendar.Event, error)) error {
+	var failure error
+	for _, mv := range result {
+		if mv.err != nil {
+			continue
+		}
+		patch := &calendar.Event{
+			Start: &calendar.EventDateTime{DateTime: mv.to.StartTime.Format(time.RFC3339)},
+			End:   &calendar.EventDateTime{DateTime: mv.to.EndTime.Format(time.RFC3339)},
+		}
+		err := withRetry(func() error {
+			return eventPatcher(mv.event.Id, patch)
+		})
+		if err != nil {
+			mv.err = err
+			failure = fmt.Errorf("unable to move event %q: %w", mv.event.Summary, err)
+			continue
+		}
+		for _, slot := range mv.split {
+			if _, err := insertWithRetry(splitEvent(mv.event, slot), eventInserter); err != nil {
+				mv.err = err
+				failure = fmt.Errorf("unable to split event %q: %w", mv.event.Summary, err)
+				break
+			}
+		}
+	}
+	return failure
+}
+
+// replanCmd moves calgo focus events away from meetings that landed on them
+var replanCmd = &cobra.Command{
+	Use:   "replan [DAY EXPRESSION]/[RANGE EXPRESSION]",
+	Short: "Move focus time out of the way of new meetings",
+	Long: `Find the focus events calgo created that conflict with other events, and move
+them to the next free slots, keeping the overall focus time. An event which no
+free slot fits is split to shorter ones, down to --min-focus-block. Events
+created by other means are never touched.`,
+	Example: "$ calgo replan m-f",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := validateDateExpressionArgs(args); err != nil {
+			return err
+		}
+		_, err := getSlotStrategy(strategy)
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, tmax, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		if today := startOfDay(clock()); tmin.Before(today) {
+			tmin = today
+		}
+		srv := google_calendar.Service()
+		events, err := srv.Events.List(calendarID).
+			ShowDeleted(false).
+			SingleEvents(true).
+			TimeMin(tmin.Format(time.RFC3339)).
+			TimeMax(tmax.Format(time.RFC3339)).
+			OrderBy(sortField).
+			Do()
+		if err != nil {
+			return fmt.Errorf("unable to retrieve events: %w", err)
+		}
+		conflicts, err := conflictingFocusEvents(events.Items)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) == 0 {
+			fmt.Println("No conflicts, nothing to replan.")
+			return nil
+		}
+
+		p := &Plan{
+			date:             tmin,
+			endDate:          tmax,
+			now:              clock,
+			calendarId:       calendarID,
+			strategy:         strategy,
+			minFocusDuration: minFocusBlock,
+			events:           newEvents(),
+		}
+		for _, e := range events.Items {
+			if !containsEvent(conflicts, e) {
+				p.events.insert(e)
+			}
+		}
+		result := p.replan(conflicts)
+		fmt.Print(result)
+		if interactive {
+			apply, err := confirm("Move the events?", true)
+			if err != nil || !apply {
+				return err
+			}
+		}
+		changed(calendarID)
+		err = applyMoves(result, func(id string, patch *calendar.Event) error {
+			_, err := srv.Events.Patch(calendarID, id, patch).Do()
+			return err
+		}, func(event *calendar.Event) (*calendar.Event, error) {
+			return insertEvent(srv, calendarID, event)
+		})
+		if err != nil {
+			log.Println(result)
+		}
+		return err
+	},
+}
+
+func containsEvent(events []*calendar.Event, e *calendar.Event) bool {
+	for _, c := range events {
+		if c == e {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	replanCmd.Flags().BoolVar(&interactive, "interactive", true, "Ask before moving the events")
+	replanCmd.Flags().DurationVar(&minFocusBlock, "min-focus-block", time.Minute*30, "shortest focus event a moved event is split to, when no free slot fits it whole (e.g 25m)")
+	replanCmd.Flags().StringVar(&strategy, "strategy", defaultStrategy, fmt.Sprintf("how to pick a free slot for the moved events, one of %s", strategyNames()))
+	rootCmd.AddCommand(replanCmd)
+}
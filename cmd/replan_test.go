@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestReplan(t *testing.T) {
+	nonInteractiveCommit(t)
+	meetings := existingMeetings(
+		// landed on top of the first focus event
+		[2]time.Time{at(9, 30), at(10, 30)},
+	)
+	declined := existingMeetings([2]time.Time{at(11, 0), at(12, 0)})[0]
+	declined.Attendees = []*calendar.EventAttendee{{Self: true, ResponseStatus: "declined"}}
+	conflicting := committedFocusEvent("f1", at(9, 0), time.Hour)
+	events := append(meetings,
+		declined,
+		conflicting,
+		committedFocusEvent("f2", at(11, 0), time.Hour),
+	)
+
+	conflicts, err := conflictingFocusEvents(events)
+	assert.NoError(t, err)
+	assert.Equal(t, []*calendar.Event{conflicting}, conflicts)
+
+	p := &Plan{
+		now:    fixedClock(at(9, 40)),
+		date:   at(0, 0),
+		events: newEvents(),
+	}
+	for _, e := range events {
+		if e != conflicting {
+			p.events.insert(e)
+		}
+	}
+	result := p.replan(conflicts)
+	assert.Len(t, result, 1)
+	assert.NoError(t, result[0].err)
+	assert.Equal(t, Slot{StartTime: at(12, 0), EndTime: at(13, 0)}, result[0].to)
+
+	patched := map[string]*calendar.Event{}
+	err = applyMoves(result, func(id string, patch *calendar.Event) error {
+		patched[id] = patch
+		return nil
+	}, (&fakeCalendar{}).insert)
+	assert.NoError(t, err)
+	assert.Len(t, patched, 1)
+	assert.Equal(t, at(12, 0).Format(time.RFC3339), patched["f1"].Start.DateTime)
+	assert.Equal(t, at(13, 0).Format(time.RFC3339), patched["f1"].End.DateTime)
+}
+
+func TestReplanSplitsAcrossGaps(t *testing.T) {
+	nonInteractiveCommit(t)
+	// the 2h block lost its place, only 1h gaps are left on the day
+	events := existingMeetings(
+		[2]time.Time{at(8, 0), at(10, 0)},
+		[2]time.Time{at(11, 0), at(14, 0)},
+		[2]time.Time{at(15, 0), at(20, 0)},
+	)
+	conflicting := committedFocusEvent("f1", at(8, 0), 2*time.Hour)
+	p := &Plan{
+		now:              fixedClock(at(7, 0)),
+		date:             at(0, 0),
+		minFocusDuration: 30 * time.Minute,
+		events:           newEvents(),
+	}
+	p.events.addAll(events)
+
+	result := p.replan([]*calendar.Event{conflicting})
+	assert.NoError(t, result[0].err)
+	assert.Equal(t, Slot{StartTime: at(10, 0), EndTime: at(11, 0)}, result[0].to)
+	assert.Equal(t, []Slot{{StartTime: at(14, 0), EndTime: at(15, 0)}}, result[0].split)
+	assert.Zero(t, result[0].missing)
+	assert.Contains(t, result.String(), "split")
+
+	f := &fakeCalendar{}
+	var patched []string
+	err := applyMoves(result, func(id string, patch *calendar.Event) error {
+		patched = append(patched, id)
+		return nil
+	}, func(event *calendar.Event) (*calendar.Event, error) {
+		assert.Equal(t, kindFocus, calgoKind(event))
+		assert.Equal(t, "previous", event.ExtendedProperties.Private[calgoPlanIdProperty])
+		assert.Equal(t, at(14, 0).Format(time.RFC3339), event.Start.DateTime)
+		return f.insert(event)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f1"}, patched)
+	assert.Equal(t, []string{"id1"}, f.inserted)
+}
+
+func TestReplanNoSlot(t *testing.T) {
+	p := &Plan{
+		now:    fixedClock(at(19, 30)),
+		date:   at(0, 0),
+		events: newEvents(),
+	}
+	result := p.replan([]*calendar.Event{committedFocusEvent("f1", at(9, 0), time.Hour)})
+	assert.Error(t, result[0].err)
+	assert.Contains(t, result.String(), "stuck")
+}
+
+func TestConflictsWithCalgoMeetings(t *testing.T) {
+	meeting := newMeetingEvent(Meeting{Title: "retro", Duration: time.Hour}, at(9, 30))
+	meeting.Id = "m1"
+	conflicting := committedFocusEvent("f1", at(9, 0), time.Hour)
+
+	conflicts, err := conflictingFocusEvents([]*calendar.Event{meeting, conflicting})
+	assert.NoError(t, err)
+	assert.Equal(t, []*calendar.Event{conflicting}, conflicts)
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// retryAttempts is how many times a request is sent before giving up
+const retryAttempts = 4
+
+// retryBackoff is the wait before the first retry, it doubles on every retry
+var retryBackoff = time.Second
+
+// sleep is swapped in tests to avoid waiting on retries
+var sleep = time.Sleep
+
+// isRetryable tells if a failed request is worth sending again, i.e. the
+// request was rate limited or the server failed
+func isRetryable(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// withRetry calls the request until it succeeds, fails with an error that is
+// not retryable, or runs out of attempts. Waits with exponential backoff
+// between the attempts.
+func withRetry(request func() error) error {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := request()
+		if err == nil || !isRetryable(err) || attempt == retryAttempts {
+			return err
+		}
+		log.Printf("request failed, retrying in %s: %v\n", backoff, err)
+		sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// newEventId creates an event id on the client. Event ids are 5 to 1024
+// characters of base32hex, which the hex digits are part of.
+func newEventId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isConflict(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
+}
+
+// insertWithRetry inserts the event like withRetry. An insert is not
+// idempotent, one which failed with a server error may have been created
+// anyway, so the event gets its id on the client and a conflict on a retry
+// means an earlier attempt created it. The given event is left without an id.
+func insertWithRetry(event *calendar.Event, insert func(event *calendar.Event) (*calendar.Event, error)) (*calendar.Event, error) {
+	withId := *event
+	withId.Id = newEventId()
+	var created *calendar.Event
+	attempt := 0
+	err := withRetry(func() (err error) {
+		attempt++
+		created, err = insert(&withId)
+		if attempt > 1 && isConflict(err) {
+			log.Printf("event %q was created by an earlier attempt\n", withId.Summary)
+			return nil
+		}
+		return err
+	})
+	if err == nil && created == nil {
+		created = &withId
+	}
+	return created, err
+}
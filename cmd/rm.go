@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// scopes of removing an instance of a recurring event
+const (
+	scopeThis      = "this"
+	scopeFollowing = "following"
+	scopeAll       = "all"
+)
+
+var rmScope string
+
+// untilFormat is the form of UNTIL in recurrence rules, untilDateFormat
+// of an all-day series
+const (
+	untilFormat     = "20060102T150405Z"
+	untilDateFormat = "20060102"
+)
+
+// truncateRecurrence ends the RRULEs of a recurrence at the given time,
+// replacing any COUNT or UNTIL they had. UNTIL of an all-day series is a
+// date.
+func truncateRecurrence(recurrence []string, until time.Time, allDay bool) []string {
+	untilValue := until.UTC().Format(untilFormat)
+	if allDay {
+		untilValue = until.Format(untilDateFormat)
+	}
+	truncated := make([]string, 0, len(recurrence))
+	for _, rule := range recurrence {
+		if strings.HasPrefix(rule, "RRULE:") {
+			var kept []string
+			for _, part := range strings.Split(strings.TrimPrefix(rule, "RRULE:"), ";") {
+				name, _, _ := strings.Cut(part, "=")
+				if part == "" || name == "COUNT" || name == "UNTIL" {
+					continue
+				}
+				kept = append(kept, part)
+			}
+			rule = "RRULE:" + strings.Join(append(kept, "UNTIL="+untilValue), ";")
+		}
+		truncated = append(truncated, rule)
+	}
+	return truncated
+}
+
+// rmCmd deletes an event
+var rmCmd = &cobra.Command{
+	Use:   "rm SELECTOR",
+	Short: "Delete an event",
+	Long: `Delete an event. The event is selected by its index in the last list, by a
+prefix of its id, or by a part of its title. For an instance of a recurring
+event, --scope chooses whether to delete this instance only, this and the
+following instances, or the whole series.`,
+	Example: `$ calgo rm 2
+$ calgo rm standup --scope following --yes`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		switch rmScope {
+		case scopeThis, scopeFollowing, scopeAll:
+			return nil
+		}
+		return fmt.Errorf("--scope must be one of %s, %s or %s", scopeThis, scopeFollowing, scopeAll)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := google_calendar.Service()
+		event, calendarId, err := selectEvent(srv, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Print(eventString(event))
+		scope := rmScope
+		if event.RecurringEventId == "" {
+			scope = scopeThis
+		}
+		if ok, err := confirmChange(fmt.Sprintf("Delete %s?", map[string]string{
+			scopeThis:      "this event",
+			scopeFollowing: "this and the following events",
+			scopeAll:       "all the events of the series",
+		}[scope])); err != nil || !ok {
+			return err
+		}
+
+		changed(calendarId)
+		err = withRetry(func() error {
+			switch scope {
+			case scopeAll:
+				return srv.Events.Delete(calendarId, event.RecurringEventId).SendUpdates("all").Do()
+			case scopeFollowing:
+				series, err := srv.Events.Get(calendarId, event.RecurringEventId).Do()
+				if err != nil {
+					return err
+				}
+				start, _, err := eventTimes(event)
+				if err != nil {
+					return err
+				}
+				patch := &calendar.Event{Recurrence: truncateRecurrence(series.Recurrence, start.Add(-time.Second), event.Start.Date != "")}
+				_, err = srv.Events.Patch(calendarId, series.Id, patch).SendUpdates("all").Do()
+				return err
+			default:
+				return srv.Events.Delete(calendarId, event.Id).SendUpdates("all").Do()
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("unable to delete event: %w", err)
+		}
+		fmt.Println("deleted")
+		return nil
+	},
+}
+
+func init() {
+	rmCmd.Flags().StringVar(&rmScope, "scope", scopeThis, fmt.Sprintf("what to delete of a recurring event, one of %s, %s or %s", scopeThis, scopeFollowing, scopeAll))
+	addSelectorFlags(rmCmd)
+	rootCmd.AddCommand(rmCmd)
+}
@@ -29,17 +29,23 @@ to quickly create a Cobra application.`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	err := rootCmd.Execute()
+	tellAgent()
+	cobra.CheckErr(err)
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, func() { cobra.CheckErr(initClock()) })
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.calgo.yaml)")
+	rootCmd.PersistentFlags().StringVar(&nowFlag, "now", "", "pretend the current time is the given time (e.g 2022-08-30T19:50)")
+	rootCmd.PersistentFlags().MarkHidden("now")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "read the events from the local cache without syncing it")
+	rootCmd.PersistentFlags().BoolVar(&refresh, "refresh", false, "drop the cached events and sync them again")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
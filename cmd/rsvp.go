@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var rsvpComment string
+
+// responses maps the rsvp answers to the attendee response status
+var responses = map[string]string{
+	"accept":    "accepted",
+	"decline":   "declined",
+	"tentative": "tentative",
+}
+
+// rsvpPatch creates a patch setting the response of the user to the event.
+// The attendees are patched as a whole, so all of them are in the patch.
+func rsvpPatch(e *calendar.Event, answer, comment string) (*calendar.Event, error) {
+	patch := &calendar.Event{}
+	var found bool
+	for _, a := range e.Attendees {
+		attendee := *a
+		if attendee.Self {
+			attendee.ResponseStatus = responses[answer]
+			attendee.Comment = comment
+			found = true
+		}
+		patch.Attendees = append(patch.Attendees, &attendee)
+	}
+	if !found {
+		return nil, fmt.Errorf("you are not an attendee of %q", e.Summary)
+	}
+	return patch, nil
+}
+
+// rsvpCmd responds to an invitation
+var rsvpCmd = &cobra.Command{
+	Use:   "rsvp SELECTOR accept|decline|tentative",
+	Short: "Respond to an invitation",
+	Long: `Accept, decline or tentatively accept an invitation. The event is selected by
+its index in the last list, by a prefix of its id, or by a part of its title.`,
+	Example: `$ calgo rsvp 4 accept
+$ calgo rsvp "all hands" decline --comment "on vacation" --yes`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("expected an event selector and a response")
+		}
+		if _, ok := responses[args[1]]; !ok {
+			return fmt.Errorf("response must be one of %s", keys(responses))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := google_calendar.Service()
+		event, calendarId, err := selectEvent(srv, args[0])
+		if err != nil {
+			return err
+		}
+		patch, err := rsvpPatch(event, args[1], rsvpComment)
+		if err != nil {
+			return err
+		}
+		fmt.Print(eventString(event))
+		if ok, err := confirmChange(fmt.Sprintf("%s this event?", args[1])); err != nil || !ok {
+			return err
+		}
+		changed(calendarId)
+		err = withRetry(func() error {
+			_, err := srv.Events.Patch(calendarId, event.Id, patch).Do()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("unable to respond: %w", err)
+		}
+		fmt.Println(responses[args[1]])
+		return nil
+	},
+}
+
+func init() {
+	rsvpCmd.Flags().StringVar(&rsvpComment, "comment", "", "optional comment to the organizer")
+	addSelectorFlags(rsvpCmd)
+	rootCmd.AddCommand(rsvpCmd)
+}
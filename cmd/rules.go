@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"google.golang.org/api/calendar/v3"
+)
+
+// rule tags the events matching all of its conditions, the conditions
+// which are not set always match. A rule with a color sets the Google
+// Calendar color id of the events it tags.
+type rule struct {
+	Tag string `mapstructure:"tag"`
+	// Title is a regular expression matched against the title
+	Title string `mapstructure:"title"`
+	// Attendees is the exact number of attendees
+	Attendees int `mapstructure:"attendees"`
+	// Domain matches when any attendee has an email on that domain
+	Domain string `mapstructure:"domain"`
+	// OutsideDomain matches when any attendee has an email on another domain
+	OutsideDomain string `mapstructure:"outsideDomain"`
+	// Type is the event type, e.g focusTime or outOfOffice
+	Type  string `mapstructure:"type"`
+	Color string `mapstructure:"color"`
+
+	title *regexp.Regexp
+}
+
+// defaultRules are used when the config has no rules section
+var defaultRules = []rule{
+	{Tag: "1:1", Attendees: 2},
+	{Tag: "focus", Type: "focusTime"},
+}
+
+// tagRules are the rules loaded by loadRules
+var tagRules []rule
+
+// loadRules reads the rules section of the config
+func loadRules() ([]rule, error) {
+	rules := append([]rule(nil), defaultRules...)
+	if viper.IsSet("rules") {
+		rules = nil
+		if err := viper.UnmarshalKey("rules", &rules); err != nil {
+			return nil, fmt.Errorf("failed reading the rules: %w", err)
+		}
+	}
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func (r *rule) compile() error {
+	if r.Tag == "" {
+		return fmt.Errorf("a rule must have a tag")
+	}
+	if r.Title == "" {
+		return nil
+	}
+	var err error
+	r.title, err = regexp.Compile(r.Title)
+	if err != nil {
+		return fmt.Errorf("invalid title of rule %q: %w", r.Tag, err)
+	}
+	return nil
+}
+
+func emailDomain(email string) string {
+	_, domain, _ := strings.Cut(email, "@")
+	return strings.ToLower(domain)
+}
+
+func (r *rule) matches(e *calendar.Event) bool {
+	if r.title != nil && !r.title.MatchString(e.Summary) {
+		return false
+	}
+	if r.Attendees > 0 && len(e.Attendees) != r.Attendees {
+		return false
+	}
+	if r.Type != "" && r.Type != e.EventType {
+		return false
+	}
+	if r.Domain != "" && !anyAttendee(e, func(domain string) bool { return domain == strings.ToLower(r.Domain) }) {
+		return false
+	}
+	if r.OutsideDomain != "" && !anyAttendee(e, func(domain string) bool { return domain != strings.ToLower(r.OutsideDomain) }) {
+		return false
+	}
+	return true
+}
+
+func anyAttendee(e *calendar.Event, domainMatches func(domain string) bool) bool {
+	for _, a := range e.Attendees {
+		if domainMatches(emailDomain(a.Email)) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventTags returns the sorted tags of the rules matching the event
+func eventTags(e *calendar.Event, rules []rule) []string {
+	var tags []string
+	for i := range rules {
+		if rules[i].matches(e) && !contains(tags, rules[i].Tag) {
+			tags = append(tags, rules[i].Tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// eventColor returns the color of the first matching rule with a color
+func eventColor(e *calendar.Event, rules []rule) string {
+	for i := range rules {
+		if rules[i].Color != "" && rules[i].matches(e) {
+			return rules[i].Color
+		}
+	}
+	return ""
+}
+
+// hasTags tells if the event has all the given tags
+func hasTags(e *calendar.Event, rules []rule, tags []string) bool {
+	eTags := eventTags(e, rules)
+	for _, t := range tags {
+		if !contains(eTags, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+const testRules = `
+rules:
+  - tag: interview
+    title: (?i)interview
+    color: "11"
+  - tag: external
+    outsideDomain: example.com
+  - tag: partner
+    domain: partner.com
+    color: "3"
+  - tag: 1:1
+    attendees: 2
+  - tag: focus
+    type: focusTime
+`
+
+func loadTestRules(t *testing.T) []rule {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigType("yaml")
+	assert.NoError(t, viper.ReadConfig(strings.NewReader(testRules)))
+	rules, err := loadRules()
+	assert.NoError(t, err)
+	return rules
+}
+
+func withAttendees(summary string, emails ...string) *calendar.Event {
+	e := &calendar.Event{Summary: summary}
+	for _, email := range emails {
+		e.Attendees = append(e.Attendees, &calendar.EventAttendee{Email: email})
+	}
+	return e
+}
+
+func TestEventTags(t *testing.T) {
+	rules := loadTestRules(t)
+	cases := []struct {
+		name   string
+		event  *calendar.Event
+		wanted []string
+	}{
+		{name: "one on one", event: withAttendees("sync", "me@example.com", "dana@example.com"), wanted: []string{"1:1"}},
+		{name: "interview", event: withAttendees("Interview: backend", "me@example.com", "a@example.com", "b@example.com"), wanted: []string{"interview"}},
+		{name: "external", event: withAttendees("kickoff", "me@example.com", "joe@Partner.com", "a@example.com"), wanted: []string{"external", "partner"}},
+		{name: "focus", event: &calendar.Event{Summary: "Focus Time", EventType: "focusTime"}, wanted: []string{"focus"}},
+		{name: "untagged", event: &calendar.Event{Summary: "lunch"}, wanted: nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wanted, eventTags(tc.event, rules))
+		})
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	viper.Reset()
+	rules, err := loadRules()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1:1"}, eventTags(withAttendees("sync", "a@x.com", "b@x.com"), rules))
+}
+
+func TestInvalidRule(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("rules", []map[string]interface{}{{"tag": "bad", "title": "("}})
+	_, err := loadRules()
+	assert.Error(t, err)
+}
+
+func TestRecolored(t *testing.T) {
+	rules := loadTestRules(t)
+	interview := withAttendees("interview", "a@example.com", "b@example.com", "c@example.com")
+	colored := withAttendees("interview", "a@example.com", "b@example.com", "c@example.com")
+	colored.ColorId = "11"
+	partner := withAttendees("kickoff", "joe@partner.com", "a@example.com", "b@example.com")
+
+	changes := recolored([]*calendar.Event{interview, colored, partner, {Summary: "lunch"}}, rules)
+	assert.Equal(t, map[*calendar.Event]string{interview: "11", partner: "3"}, changes)
+}
+
+func TestListTemplate(t *testing.T) {
+	tagRules = defaultRules
+	e := withAttendees("sync", "a@x.com", "b@x.com")
+	e.Start = &calendar.EventDateTime{DateTime: at(10, 0).Format(time.RFC3339)}
+	e.End = &calendar.EventDateTime{DateTime: at(10, 30).Format(time.RFC3339)}
+	te := newTemplateEvent(1, e)
+	assert.Equal(t, at(10, 0), te.Start)
+	assert.Equal(t, "sync", te.Summary)
+	assert.Equal(t, []string{"1:1"}, te.Tags)
+	assert.True(t, hasTags(e, defaultRules, []string{"1:1"}))
+	assert.False(t, hasTags(e, defaultRules, []string{"1:1", "focus"}))
+}
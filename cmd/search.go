@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchSince string
+	searchUntil string
+)
+
+// parseDay parses a date in the form of 2006-01-02, or a day expression
+// like -30 or +7 relative to now
+func parseDay(now time.Time, s string) (time.Time, error) {
+	if d, err := time.ParseInLocation(allDayFormat, s, now.Location()); err == nil {
+		return d, nil
+	}
+	d, err := timeFromExpression(now, s)
+	if err != nil {
+		return d, fmt.Errorf("unsupported day %q, use a date like 2023-09-25 or a day expression like -30", s)
+	}
+	return d, nil
+}
+
+// searchCmd lists the events matching a text over a long range of days
+var searchCmd = &cobra.Command{
+	Use:   "search TEXT",
+	Short: "Search events",
+	Long: `Search the title, description, location and attendees of the events from
+--since until --until. The list filters narrow the results further.`,
+	Example: `$ calgo search "design review" --since -90
+$ calgo search offsite --since 2023-01-01 --until 2023-12-31 --has-meet`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("expected a text to search")
+		}
+		return listFilter.validate()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, err := parseDay(clock(), searchSince)
+		if err != nil {
+			return err
+		}
+		until, err := parseDay(clock(), searchUntil)
+		if err != nil {
+			return err
+		}
+		if until.Before(since) {
+			return fmt.Errorf("--until must not be before --since")
+		}
+		listFilter.query = strings.TrimSpace(strings.Join(append(args, listFilter.query), " "))
+		return listEvents(midnight(since), midnight(until).AddDate(0, 0, 1), 0, true)
+	},
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchSince, "since", "-30", "first day to search, a date or a day expression")
+	searchCmd.Flags().StringVar(&searchUntil, "until", "+90", "last day to search, a date or a day expression")
+	addListFlags(searchCmd)
+	rootCmd.AddCommand(searchCmd)
+}
@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	// assumeYes skips the confirmations of commands changing events
+	assumeYes bool
+	// searchIn is the range expression searched for events by a selector
+	searchIn string
+)
+
+// searchDaysBack and searchDaysAhead are the days searched for events by a
+// selector, when no --in range is given
+const (
+	searchDaysBack  = 1
+	searchDaysAhead = 30
+)
+
+// listedEvent is an event shown by the last list command, so it can be
+// selected by its index
+type listedEvent struct {
+	Id         string `json:"id"`
+	CalendarId string `json:"calendarId"`
+	Summary    string `json:"summary"`
+}
+
+func lastListPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "calgo", "last-list.json"), nil
+}
+
+// saveLastList keeps the listed events and their calendars for selecting
+// them by index later. Events without a calendar are of the --calendar-id.
+func saveLastList(events []*calendar.Event, calendarOf map[*calendar.Event]string) error {
+	path, err := lastListPath()
+	if err != nil {
+		return err
+	}
+	listed := make([]listedEvent, 0, len(events))
+	for _, e := range events {
+		calendarId, ok := calendarOf[e]
+		if !ok {
+			calendarId = calendarID
+		}
+		listed = append(listed, listedEvent{Id: e.Id, CalendarId: calendarId, Summary: e.Summary})
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(listed)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+func loadLastList() ([]listedEvent, error) {
+	path, err := lastListPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var listed []listedEvent
+	return listed, json.Unmarshal(b, &listed)
+}
+
+// selectEventId resolves a selector to an event id. A selector is either an
+// index of an event in the last list, a prefix of an event id, or a part of
+// the summary of a single event out of the candidates.
+func selectEventId(selector string, lastList []listedEvent, candidates []*calendar.Event) (string, error) {
+	if i, err := strconv.Atoi(selector); err == nil && i >= 1 && i <= len(lastList) {
+		return lastList[i-1].Id, nil
+	}
+	var byId, bySummary []*calendar.Event
+	for _, e := range candidates {
+		if strings.HasPrefix(e.Id, selector) {
+			byId = append(byId, e)
+		}
+		if strings.Contains(strings.ToLower(e.Summary), strings.ToLower(selector)) {
+			bySummary = append(bySummary, e)
+		}
+	}
+	for _, matches := range [][]*calendar.Event{byId, bySummary} {
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0].Id, nil
+		default:
+			var names []string
+			for _, m := range matches {
+				start, _, _ := eventTimes(m)
+				names = append(names, fmt.Sprintf("%s %s %q", m.Id, start.Format(dayFormat), m.Summary))
+			}
+			return "", fmt.Errorf("%q matches %d events, be more specific:\n%s", selector, len(matches), strings.Join(names, "\n"))
+		}
+	}
+	return "", fmt.Errorf("no event matches %q", selector)
+}
+
+// selectEvent finds the single event of the selector, see selectEventId,
+// along with its calendar. An event listed from another calendar is on
+// that calendar, otherwise it is on the --calendar-id.
+func selectEvent(service *calendar.Service, selector string) (*calendar.Event, string, error) {
+	lastList, err := loadLastList()
+	if err != nil {
+		return nil, "", err
+	}
+	calendarId := calendarID
+	var candidates []*calendar.Event
+	if i, err := strconv.Atoi(selector); err != nil || i < 1 || i > len(lastList) {
+		tmin := midnight(clock()).AddDate(0, 0, -searchDaysBack)
+		tmax := midnight(clock()).AddDate(0, 0, searchDaysAhead)
+		if searchIn != "" {
+			tmin, tmax, err = getTimeBoundaries([]string{searchIn})
+			if err != nil {
+				return nil, "", err
+			}
+		}
+		events, err := service.Events.List(calendarId).
+			ShowDeleted(false).
+			SingleEvents(true).
+			TimeMin(tmin.Format(time.RFC3339)).
+			TimeMax(tmax.Format(time.RFC3339)).
+			OrderBy(sortField).
+			Do()
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to retrieve events: %w", err)
+		}
+		candidates = events.Items
+	} else if lastList[i-1].CalendarId != "" {
+		calendarId = lastList[i-1].CalendarId
+	}
+	id, err := selectEventId(selector, lastList, candidates)
+	if err != nil {
+		return nil, "", err
+	}
+	event, err := service.Events.Get(calendarId, id).Do()
+	return event, calendarId, err
+}
+
+// confirmChange asks before changing an event, unless --yes
+func confirmChange(message string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	return confirm(message, false)
+}
+
+// addSelectorFlags adds the flags of the commands working on a selected event
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "don't ask for confirmation, for scripting")
+	cmd.Flags().StringVar(&searchIn, "in", "", fmt.Sprintf("day or range expression to search the event in (default from %d days back to %d days ahead)", searchDaysBack, searchDaysAhead))
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+func TestSelectEventId(t *testing.T) {
+	events := existingMeetings(
+		[2]time.Time{at(9, 0), at(10, 0)},
+		[2]time.Time{at(11, 0), at(12, 0)},
+	)
+	events[0].Id, events[0].Summary = "abc123", "Standup"
+	events[1].Id, events[1].Summary = "abd456", "1:1 with dana"
+	lastList := []listedEvent{{Id: "listed1"}, {Id: "listed2"}}
+
+	cases := []struct {
+		selector  string
+		wantedId  string
+		wantedErr bool
+	}{
+		{selector: "2", wantedId: "listed2"},
+		{selector: "abc", wantedId: "abc123"},
+		{selector: "dana", wantedId: "abd456"},
+		{selector: "STANDUP", wantedId: "abc123"},
+		{selector: "ab", wantedErr: true},
+		{selector: "retro", wantedErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.selector, func(t *testing.T) {
+			id, err := selectEventId(tc.selector, lastList, events)
+			if tc.wantedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantedId, id)
+		})
+	}
+}
+
+func TestLastList(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	listed, err := loadLastList()
+	assert.NoError(t, err)
+	assert.Empty(t, listed)
+
+	assert.NoError(t, saveLastList(existingMeetings([2]time.Time{at(9, 0), at(10, 0)}), nil))
+	listed, err = loadLastList()
+	assert.NoError(t, err)
+	assert.Equal(t, []listedEvent{{Id: "1", CalendarId: "primary", Summary: "meeting 1"}}, listed)
+}
+
+func TestTruncateRecurrence(t *testing.T) {
+	until := time.Date(2023, 9, 25, 8, 59, 59, 0, time.UTC)
+	assert.Equal(t,
+		[]string{"RRULE:FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20230925T085959Z", "EXDATE:20230918T090000Z"},
+		truncateRecurrence([]string{"RRULE:FREQ=WEEKLY;COUNT=10;BYDAY=MO,WE", "EXDATE:20230918T090000Z"}, until, false))
+	assert.Equal(t,
+		[]string{"RRULE:FREQ=DAILY;UNTIL=20230925T085959Z"},
+		truncateRecurrence([]string{"RRULE:UNTIL=20240101T000000Z;FREQ=DAILY"}, until, false))
+	assert.Equal(t,
+		[]string{"RRULE:FREQ=DAILY;UNTIL=20230925T085959Z"},
+		truncateRecurrence([]string{"RRULE:COUNT=5;FREQ=DAILY"}, until, false))
+	assert.Equal(t,
+		[]string{"RRULE:UNTIL=20230925T085959Z"},
+		truncateRecurrence([]string{"RRULE:COUNT=5"}, until, false))
+}
+
+func TestTruncateAllDayRecurrence(t *testing.T) {
+	// the day before the first removed instance, of 26 Sep
+	until := time.Date(2023, 9, 26, 0, 0, 0, 0, time.Local).Add(-time.Second)
+	assert.Equal(t,
+		[]string{"RRULE:FREQ=WEEKLY;BYDAY=TU;UNTIL=20230925"},
+		truncateRecurrence([]string{"RRULE:FREQ=WEEKLY;COUNT=8;BYDAY=TU"}, until, true))
+}
+
+func TestRsvpPatch(t *testing.T) {
+	e := &calendar.Event{
+		Summary: "all hands",
+		Attendees: []*calendar.EventAttendee{
+			{Email: "boss@example.com", Organizer: true, ResponseStatus: "accepted"},
+			{Email: "me@example.com", Self: true, ResponseStatus: "needsAction"},
+		},
+	}
+	patch, err := rsvpPatch(e, "decline", "on vacation")
+	assert.NoError(t, err)
+	assert.Len(t, patch.Attendees, 2)
+	assert.Equal(t, "declined", patch.Attendees[1].ResponseStatus)
+	assert.Equal(t, "on vacation", patch.Attendees[1].Comment)
+	assert.Equal(t, "needsAction", e.Attendees[1].ResponseStatus, "the event itself is not changed")
+
+	_, err = rsvpPatch(&calendar.Event{Summary: "mine"}, "accept", "")
+	assert.Error(t, err)
+}
+
+func TestMovePatch(t *testing.T) {
+	e := existingMeetings([2]time.Time{at(9, 0), at(9, 45)})[0]
+	patch, err := movePatch(e, at(15, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, at(15, 0).Format(time.RFC3339), patch.Start.DateTime)
+	assert.Equal(t, at(15, 45).Format(time.RFC3339), patch.End.DateTime)
+}
+
+func TestSelectEventOfAnotherCalendar(t *testing.T) {
+	isolatedCache(t)
+	defer func(id string) { calendarID = id }(calendarID)
+	calendarID = "primary"
+	events := existingMeetings([2]time.Time{at(9, 0), at(10, 0)})
+	assert.NoError(t, saveLastList(events, map[*calendar.Event]string{events[0]: "team@example.com"}))
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_ = json.NewEncoder(w).Encode(events[0])
+	}))
+	defer server.Close()
+	srv, err := calendar.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+
+	event, calendarId, err := selectEvent(srv, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "meeting 1", event.Summary)
+	assert.Equal(t, "team@example.com", calendarId)
+	assert.Equal(t, []string{"/calendars/team@example.com/events/1"}, requested)
+	assert.Equal(t, "primary", calendarID, "the --calendar-id is left as is")
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// seriesDaysAhead limits the instances shown of a series without an end
+const seriesDaysAhead = 90
+
+// seriesId returns the id of the recurring event an event belongs to
+func seriesId(e *calendar.Event) (string, error) {
+	switch {
+	case e.RecurringEventId != "":
+		return e.RecurringEventId, nil
+	case len(e.Recurrence) > 0:
+		return e.Id, nil
+	}
+	return "", fmt.Errorf("%q is not a recurring event", e.Summary)
+}
+
+// seriesException tells how an instance differs from its series, empty
+// if it does not
+func seriesException(e *calendar.Event) string {
+	if e.Status == "cancelled" {
+		return "cancelled"
+	}
+	if e.OriginalStartTime == nil || e.Start == nil {
+		return ""
+	}
+	original, err := parseEventDateTime(e.OriginalStartTime)
+	if err != nil {
+		return ""
+	}
+	start, err := parseEventDateTime(e.Start)
+	if err != nil || start.Equal(original) {
+		return ""
+	}
+	return "moved from " + original.Format(dayFormat+" "+time.Kitchen)
+}
+
+// seriesString presents a recurring event with its instances and exceptions
+func seriesString(master *calendar.Event, instances []*calendar.Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", master.Summary)
+	for _, r := range master.Recurrence {
+		fmt.Fprintf(&b, "  %s\n", r)
+	}
+	var exceptions int
+	for _, e := range instances {
+		exception := seriesException(e)
+		if exception != "" {
+			exceptions++
+		}
+		if e.Status == "cancelled" {
+			// cancelled instances have only their original start
+			original, _ := parseEventDateTime(e.OriginalStartTime)
+			fmt.Fprintf(&b, "%s %-17s   (%s)\n", original.Format(dayFormat), original.Format(time.Kitchen), exception)
+			continue
+		}
+		start, _, _ := eventTimes(e)
+		line := strings.TrimSuffix(eventString(e), "\n")
+		if exception != "" {
+			line += " (" + exception + ")"
+		}
+		fmt.Fprintf(&b, "%s %s\n", start.Format(dayFormat), line)
+	}
+	fmt.Fprintf(&b, "%d instances, %d exceptions\n", len(instances), exceptions)
+	return b.String()
+}
+
+// seriesCmd shows all the instances of a recurring event
+var seriesCmd = &cobra.Command{
+	Use:   "series SELECTOR",
+	Short: "Show the whole series of a recurring event",
+	Long: fmt.Sprintf(`Show the recurrence of an event and its instances, with the cancelled and
+moved ones as exceptions. Series without an end are shown up to %d days ahead.
+The event is selected by its index in the last list, by a prefix of its id,
+or by a part of its title.`, seriesDaysAhead),
+	Example: "$ calgo series standup",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := google_calendar.Service()
+		event, calendarId, err := selectEvent(srv, args[0])
+		if err != nil {
+			return err
+		}
+		id, err := seriesId(event)
+		if err != nil {
+			return err
+		}
+		master, err := srv.Events.Get(calendarId, id).Do()
+		if err != nil {
+			return fmt.Errorf("unable to retrieve the series: %w", err)
+		}
+		var instances []*calendar.Event
+		err = srv.Events.Instances(calendarId, id).
+			ShowDeleted(true).
+			TimeMax(midnight(clock()).AddDate(0, 0, seriesDaysAhead).Format(time.RFC3339)).
+			Pages(context.Background(), func(page *calendar.Events) error {
+				instances = append(instances, page.Items...)
+				return nil
+			})
+		if err != nil {
+			return fmt.Errorf("unable to retrieve the instances: %w", err)
+		}
+		fmt.Print(seriesString(master, instances))
+		return nil
+	},
+}
+
+func init() {
+	addSelectorFlags(seriesCmd)
+	rootCmd.AddCommand(seriesCmd)
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestSeriesException(t *testing.T) {
+	original := &calendar.EventDateTime{DateTime: at(10, 0).Format(time.RFC3339)}
+	cases := []struct {
+		name   string
+		event  *calendar.Event
+		wanted string
+	}{
+		{
+			name:   "unchanged",
+			event:  &calendar.Event{Start: original, OriginalStartTime: original},
+			wanted: "",
+		},
+		{
+			name:   "cancelled",
+			event:  &calendar.Event{Status: "cancelled", OriginalStartTime: original},
+			wanted: "cancelled",
+		},
+		{
+			name: "moved",
+			event: &calendar.Event{
+				Start:             &calendar.EventDateTime{DateTime: at(14, 0).Format(time.RFC3339)},
+				OriginalStartTime: original,
+			},
+			wanted: "moved from Mon 25 Sep 10:00AM",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wanted, seriesException(tc.event))
+		})
+	}
+}
+
+func TestListMarksRecurringInstances(t *testing.T) {
+	e := &calendar.Event{
+		Id:               "standup_20230925",
+		RecurringEventId: "standup",
+		Summary:          "standup",
+		Start:            &calendar.EventDateTime{DateTime: at(10, 0).Format(time.RFC3339)},
+		End:              &calendar.EventDateTime{DateTime: at(10, 15).Format(time.RFC3339)},
+	}
+	assert.Contains(t, eventString(e), "↻   standup")
+
+	series := seriesString(&calendar.Event{Summary: "standup", Recurrence: []string{"RRULE:FREQ=DAILY"}}, []*calendar.Event{e})
+	assert.Contains(t, series, "RRULE:FREQ=DAILY")
+	assert.Contains(t, series, "1 instances, 0 exceptions")
+}
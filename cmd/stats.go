@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var statsOutput string
+
+// hours is a duration reported in hours in the json output
+type hours time.Duration
+
+func (h hours) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(h).Hours())
+}
+
+func (h hours) String() string {
+	return time.Duration(h).String()
+}
+
+const (
+	originCalgo    = "calgo"
+	originExternal = "external"
+)
+
+type busyDay struct {
+	Date     string `json:"date"`
+	Meetings hours  `json:"meetingHours"`
+}
+
+// stats is the time spent over a range of days. The free time and the
+// fragments are measured within the working hours.
+type stats struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	MeetingCount int    `json:"meetingCount"`
+	Meetings     hours  `json:"meetingHours"`
+	Focus        hours  `json:"focusHours"`
+	Free         hours  `json:"freeHours"`
+	// Fragments is the number of free slots too short to use
+	Fragments        int              `json:"fragments"`
+	LargestFreeBlock *Slot            `json:"largestFreeBlock,omitempty"`
+	BusiestDay       *busyDay         `json:"busiestDay,omitempty"`
+	ByOrganizer      map[string]hours `json:"byOrganizer"`
+	ByAttendees      map[string]hours `json:"byAttendees"`
+	ByOrigin         map[string]hours `json:"byOrigin"`
+	ByTag            map[string]hours `json:"byTag"`
+}
+
+// attendeesBucket groups meetings by their size
+func attendeesBucket(e *calendar.Event) string {
+	switch n := len(e.Attendees); {
+	case n <= 1:
+		return "1"
+	case n == 2:
+		return "2"
+	case n <= 5:
+		return "3-5"
+	case n <= 10:
+		return "6-10"
+	default:
+		return "11+"
+	}
+}
+
+func organizer(e *calendar.Event) string {
+	if e.Organizer == nil || e.Organizer.Email == "" {
+		return "unknown"
+	}
+	return e.Organizer.Email
+}
+
+// computeStats measures the events of the days from tmin to tmax, tagged
+// by the rules. The events are expected sorted by their start.
+func computeStats(events []*calendar.Event, tmin, tmax time.Time, rules []rule) (*stats, error) {
+	s := &stats{
+		From:        tmin.Format(allDayFormat),
+		To:          tmax.Format(allDayFormat),
+		ByOrganizer: map[string]hours{},
+		ByAttendees: map[string]hours{},
+		ByOrigin:    map[string]hours{},
+		ByTag:       map[string]hours{},
+	}
+	sorted := newEvents()
+	perDay := map[string]time.Duration{}
+	// meetingsEnd is the end of the meetings so far, overlapping meetings
+	// count once in the meeting time, like the free slots skip them
+	var meetingsEnd time.Time
+	for _, e := range events {
+		sorted.insert(e)
+		if !isBusy(e) || !isAccepted(e) || e.EventType == "outOfOffice" {
+			continue
+		}
+		start, end, err := eventTimes(e)
+		if err != nil {
+			return nil, err
+		}
+		duration := end.Sub(start)
+		origin := originExternal
+		if isCalgoEvent(e) {
+			origin = originCalgo
+		}
+		s.ByOrigin[origin] += hours(duration)
+		for _, tag := range eventTags(e, rules) {
+			s.ByTag[tag] += hours(duration)
+		}
+		if isFocusEvent(e) {
+			s.Focus += hours(duration)
+			continue
+		}
+		s.MeetingCount++
+		s.ByOrganizer[organizer(e)] += hours(duration)
+		s.ByAttendees[attendeesBucket(e)] += hours(duration)
+		busyStart := start
+		if meetingsEnd.After(busyStart) {
+			busyStart = meetingsEnd
+		}
+		if end.After(busyStart) {
+			s.Meetings += hours(end.Sub(busyStart))
+			perDay[start.Format(allDayFormat)] += end.Sub(busyStart)
+			meetingsEnd = end
+		}
+	}
+
+	// the whole working hours are measured, even of today
+	p := &Plan{date: tmin, endDate: tmax, events: sorted, now: func() time.Time { return time.Time{} }}
+	for _, day := range p.days() {
+		free, err := p.freeSlots(day)
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range free {
+			s.Free += hours(slot.Duration())
+			if slot.Duration() < sliverDuration {
+				s.Fragments++
+			}
+			if s.LargestFreeBlock == nil || slot.Duration() > s.LargestFreeBlock.Duration() {
+				largest := slot
+				s.LargestFreeBlock = &largest
+			}
+		}
+		date := day.Format(allDayFormat)
+		if meetings := perDay[date]; meetings > 0 && (s.BusiestDay == nil || hours(meetings) > s.BusiestDay.Meetings) {
+			s.BusiestDay = &busyDay{Date: date, Meetings: hours(meetings)}
+		}
+	}
+	return s, nil
+}
+
+func breakdownString(b *strings.Builder, title string, breakdown map[string]hours) {
+	if len(breakdown) == 0 {
+		return
+	}
+	names := make([]string, 0, len(breakdown))
+	for name := range breakdown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(b, "by %s:\n", title)
+	for _, name := range names {
+		fmt.Fprintf(b, "  %-30s %s\n", name, breakdown[name])
+	}
+}
+
+func (s *stats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s - %s\n", s.From, s.To)
+	fmt.Fprintf(&b, "%d meetings, %s overall\n", s.MeetingCount, s.Meetings)
+	fmt.Fprintf(&b, "%s focus time, %s free time\n", s.Focus, s.Free)
+	fmt.Fprintf(&b, "%d free gaps shorter than %s\n", s.Fragments, sliverDuration)
+	if s.LargestFreeBlock != nil {
+		l := s.LargestFreeBlock
+		fmt.Fprintf(&b, "largest free block %s %s - %s (%s)\n", l.StartTime.Format(dayFormat), l.StartTime.Format(time.Kitchen), l.EndTime.Format(time.Kitchen), l.Duration())
+	}
+	if s.BusiestDay != nil {
+		fmt.Fprintf(&b, "busiest day %s with %s of meetings\n", s.BusiestDay.Date, s.BusiestDay.Meetings)
+	}
+	breakdownString(&b, "organizer", s.ByOrganizer)
+	breakdownString(&b, "attendees", s.ByAttendees)
+	breakdownString(&b, "origin", s.ByOrigin)
+	breakdownString(&b, "tag", s.ByTag)
+	return b.String()
+}
+
+// statsCmd reports how the time is spent
+var statsCmd = &cobra.Command{
+	Use:   "stats [DAY EXPRESSION]/[RANGE EXPRESSION]",
+	Short: "Report meeting, focus and free time",
+	Long: fmt.Sprintf(`Report the meeting, focus and free time of a day or a range of days, how
+fragmented the free time is by gaps shorter than %s, the largest free block,
+the busiest day, and the meeting time by organizer, by number of attendees and
+by whether calgo created the events and by the tags of the rules.`, sliverDuration),
+	Example: `$ calgo stats m-f
+$ calgo stats m-f --output json >> weekly-stats.json`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := validateDateExpressionArgs(args); err != nil {
+			return err
+		}
+		return validateOutputFormat(statsOutput)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, tmax, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		srv := google_calendar.Service()
+		items, err := cachedEvents(srv, calendarID, midnight(tmin), midnight(tmax).AddDate(0, 0, 1))
+		if err != nil {
+			return fmt.Errorf("unable to retrieve events: %w", err)
+		}
+		rules, err := loadRules()
+		if err != nil {
+			return err
+		}
+		s, err := computeStats(items, tmin, tmax, rules)
+		if err != nil {
+			return err
+		}
+		if statsOutput == outputJSON {
+			return printJSON(os.Stdout, s)
+		}
+		fmt.Print(s)
+		return nil
+	},
+}
+
+func init() {
+	addOutputFlag(statsCmd, &statsOutput)
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestComputeStats(t *testing.T) {
+	// Monday: meetings 09:00-10:00, 10:15-11:00, focus 11:00-13:00
+	// Tuesday: meeting 09:00-09:30
+	events := existingMeetings(
+		[2]time.Time{at(9, 0), at(10, 0)},
+		[2]time.Time{at(10, 15), at(11, 0)},
+		[2]time.Time{at(9, 0).AddDate(0, 0, 1), at(9, 30).AddDate(0, 0, 1)},
+	)
+	events[0].Organizer = &calendar.EventOrganizer{Email: "dana@example.com"}
+	events[0].Attendees = []*calendar.EventAttendee{{Email: "dana@example.com"}, {Email: "me@example.com", Self: true, ResponseStatus: "accepted"}}
+	events = append(events, committedFocusEvent("focus", at(11, 0), 2*time.Hour))
+
+	s, err := computeStats(events, startOfDay(at(0, 0)), endOfDay(at(0, 0).AddDate(0, 0, 1)), defaultRules)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, s.MeetingCount)
+	assert.Equal(t, hours(2*time.Hour+15*time.Minute), s.Meetings)
+	assert.Equal(t, hours(2*time.Hour), s.Focus)
+	// 24h of working hours minus the busy time
+	assert.Equal(t, hours(24*time.Hour-4*time.Hour-15*time.Minute), s.Free)
+	// the 15m gap between the meetings on Monday
+	assert.Equal(t, 1, s.Fragments)
+	assert.Equal(t, &Slot{StartTime: at(9, 30).AddDate(0, 0, 1), EndTime: at(20, 0).AddDate(0, 0, 1)}, s.LargestFreeBlock)
+	assert.Equal(t, &busyDay{Date: "2023-09-25", Meetings: hours(time.Hour + 45*time.Minute)}, s.BusiestDay)
+	assert.Equal(t, map[string]hours{
+		"dana@example.com": hours(time.Hour),
+		"unknown":          hours(time.Hour + 15*time.Minute),
+	}, s.ByOrganizer)
+	assert.Equal(t, map[string]hours{
+		"1": hours(time.Hour + 15*time.Minute),
+		"2": hours(time.Hour),
+	}, s.ByAttendees)
+	assert.Equal(t, map[string]hours{
+		originCalgo:    hours(2 * time.Hour),
+		originExternal: hours(2*time.Hour + 15*time.Minute),
+	}, s.ByOrigin)
+	assert.Equal(t, map[string]hours{"1:1": hours(time.Hour), "focus": hours(2 * time.Hour)}, s.ByTag)
+	assert.Contains(t, s.String(), "3 meetings, 2h15m0s overall")
+}
+
+func TestComputeStatsOverlappingMeetings(t *testing.T) {
+	// 09:00-10:00 and 09:30-10:30 overlap, 09:45-10:15 is within both
+	events := existingMeetings(
+		[2]time.Time{at(9, 0), at(10, 0)},
+		[2]time.Time{at(9, 30), at(10, 30)},
+		[2]time.Time{at(9, 45), at(10, 15)},
+	)
+
+	s, err := computeStats(events, startOfDay(at(0, 0)), endOfDay(at(0, 0)), defaultRules)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, s.MeetingCount)
+	assert.Equal(t, hours(90*time.Minute), s.Meetings)
+	assert.Equal(t, &busyDay{Date: "2023-09-25", Meetings: hours(90 * time.Minute)}, s.BusiestDay)
+	assert.Equal(t, hours(12*time.Hour-90*time.Minute), s.Free)
+}
+
+func TestStatsJSONInHours(t *testing.T) {
+	s := &stats{Meetings: hours(90 * time.Minute)}
+	var b bytes.Buffer
+	assert.NoError(t, printJSON(&b, s))
+	assert.Contains(t, b.String(), `"meetingHours": 1.5`)
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// morningEnd is the hour the morning ends, for the protect-mornings strategy
+const morningEnd = 12
+
+// sliverDuration is the longest free time which is considered too short to
+// be useful, i.e a lone sliver between meetings
+const sliverDuration = 30 * time.Minute
+
+// slotStrategy picks a slot for an event of at least min and at most max
+// duration out of the free slots of a day. The free slots are sorted by their
+// start time and are not overlapping. Returns false if no free slot can
+// contain the min duration.
+type slotStrategy func(free []Slot, min, max time.Duration) (Slot, bool)
+
+var slotStrategies = map[string]slotStrategy{
+	"earliest":         earliestSlot,
+	"latest":           latestSlot,
+	"protect-mornings": protectMorningsSlot,
+	"fill-fragments":   fillFragmentsSlot,
+	"largest-block":    largestBlockSlot,
+	"score":            scoreSlot,
+}
+
+func (s Slot) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+func (s Slot) fits(duration time.Duration) bool {
+	return s.Duration() >= duration
+}
+
+// head is the part of the slot from its start, up to max long
+func (s Slot) head(max time.Duration) Slot {
+	if s.fits(max) {
+		return Slot{StartTime: s.StartTime, EndTime: s.StartTime.Add(max)}
+	}
+	return s
+}
+
+// tail is the part of the slot up to its end, up to max long
+func (s Slot) tail(max time.Duration) Slot {
+	if s.fits(max) {
+		return Slot{StartTime: s.EndTime.Add(-max), EndTime: s.EndTime}
+	}
+	return s
+}
+
+func strategyNames() string {
+	names := make([]string, 0, len(slotStrategies))
+	for name := range slotStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func getSlotStrategy(name string) (slotStrategy, error) {
+	strategy, ok := slotStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, use one of %s", name, strategyNames())
+	}
+	return strategy, nil
+}
+
+// earliestSlot takes the first free slot that fits
+func earliestSlot(free []Slot, min, max time.Duration) (Slot, bool) {
+	for _, s := range free {
+		if s.fits(min) {
+			return s.head(max), true
+		}
+	}
+	return Slot{}, false
+}
+
+// latestSlot takes the end of the last free slot that fits
+func latestSlot(free []Slot, min, max time.Duration) (Slot, bool) {
+	for i := len(free) - 1; i >= 0; i-- {
+		if free[i].fits(min) {
+			return free[i].tail(max), true
+		}
+	}
+	return Slot{}, false
+}
+
+// protectMorningsSlot keeps the mornings for focus, it takes the largest free
+// slot starting before noon and falls back to the earliest slot otherwise.
+func protectMorningsSlot(free []Slot, min, max time.Duration) (Slot, bool) {
+	var best *Slot
+	for i, s := range free {
+		if s.StartTime.Hour() >= morningEnd || !s.fits(min) {
+			continue
+		}
+		if best == nil || s.Duration() > best.Duration() {
+			best = &free[i]
+		}
+	}
+	if best != nil {
+		return best.head(max), true
+	}
+	return earliestSlot(free, min, max)
+}
+
+// fillFragmentsSlot uses up the smallest free slot that fits first, leaving
+// the large blocks for later
+func fillFragmentsSlot(free []Slot, min, max time.Duration) (Slot, bool) {
+	var best *Slot
+	for i, s := range free {
+		if !s.fits(min) {
+			continue
+		}
+		if best == nil || s.Duration() < best.Duration() {
+			best = &free[i]
+		}
+	}
+	if best == nil {
+		return Slot{}, false
+	}
+	return best.head(max), true
+}
+
+// largestBlockSlot takes the start of the largest contiguous free slot
+func largestBlockSlot(free []Slot, min, max time.Duration) (Slot, bool) {
+	var best *Slot
+	for i, s := range free {
+		if !s.fits(min) {
+			continue
+		}
+		if best == nil || s.Duration() > best.Duration() {
+			best = &free[i]
+		}
+	}
+	if best == nil {
+		return Slot{}, false
+	}
+	return best.head(max), true
+}
+
+// scoreSlot tries to place the event at either end of every free slot and
+// takes the placement with the lowest penalty. Every free time left around
+// the event is a context switch, and a left over that is too short to be
+// useful is penalised more. Ties go to the earliest placement.
+func scoreSlot(free []Slot, min, max time.Duration) (Slot, bool) {
+	var best Slot
+	bestScore := -1
+	for _, s := range free {
+		if !s.fits(min) {
+			continue
+		}
+		for _, candidate := range []Slot{s.head(max), s.tail(max)} {
+			score := leftoverPenalty(candidate.StartTime.Sub(s.StartTime)) + leftoverPenalty(s.EndTime.Sub(candidate.EndTime))
+			if bestScore == -1 || score < bestScore {
+				best = candidate
+				bestScore = score
+			}
+		}
+	}
+	return best, bestScore != -1
+}
+
+func leftoverPenalty(leftover time.Duration) int {
+	switch {
+	case leftover <= 0:
+		return 0
+	case leftover < sliverDuration:
+		return 3
+	default:
+		return 1
+	}
+}
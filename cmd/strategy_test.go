@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2023, 9, 25, hour, minute, 0, 0, time.UTC)
+}
+
+func TestSlotStrategies(t *testing.T) {
+	// 08:00-09:00 meeting 09:20-09:40 meeting 11:00-15:00 meeting 16:30-20:00
+	free := []Slot{
+		{StartTime: at(8, 0), EndTime: at(9, 0)},
+		{StartTime: at(9, 20), EndTime: at(9, 40)},
+		{StartTime: at(11, 0), EndTime: at(15, 0)},
+		{StartTime: at(16, 30), EndTime: at(20, 0)},
+	}
+
+	cases := []struct {
+		strategy string
+		min      time.Duration
+		max      time.Duration
+		wanted   Slot
+	}{
+		{strategy: "earliest", min: 45 * time.Minute, max: 45 * time.Minute, wanted: Slot{at(8, 0), at(8, 45)}},
+		{strategy: "earliest", min: 20 * time.Minute, max: 2 * time.Hour, wanted: Slot{at(8, 0), at(9, 0)}},
+		{strategy: "latest", min: 45 * time.Minute, max: 45 * time.Minute, wanted: Slot{at(19, 15), at(20, 0)}},
+		{strategy: "protect-mornings", min: 45 * time.Minute, max: 45 * time.Minute, wanted: Slot{at(11, 0), at(11, 45)}},
+		{strategy: "fill-fragments", min: 20 * time.Minute, max: 45 * time.Minute, wanted: Slot{at(9, 20), at(9, 40)}},
+		{strategy: "fill-fragments", min: 45 * time.Minute, max: 45 * time.Minute, wanted: Slot{at(8, 0), at(8, 45)}},
+		{strategy: "largest-block", min: 45 * time.Minute, max: 5 * time.Hour, wanted: Slot{at(11, 0), at(15, 0)}},
+		// an hour fills the first slot with no left over
+		{strategy: "score", min: time.Hour, max: time.Hour, wanted: Slot{at(8, 0), at(9, 0)}},
+		// 45 minutes anywhere leaves free time, prefer not leaving a sliver
+		{strategy: "score", min: 45 * time.Minute, max: 45 * time.Minute, wanted: Slot{at(11, 0), at(11, 45)}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.strategy, func(t *testing.T) {
+			strategy, err := getSlotStrategy(tc.strategy)
+			assert.NoError(t, err)
+			got, ok := strategy(free, tc.min, tc.max)
+			assert.True(t, ok)
+			assert.Equal(t, tc.wanted, got)
+		})
+	}
+}
+
+func TestSlotStrategiesNoFit(t *testing.T) {
+	free := []Slot{{StartTime: at(8, 0), EndTime: at(8, 30)}}
+	for name, strategy := range slotStrategies {
+		_, ok := strategy(free, time.Hour, time.Hour)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	_, err := getSlotStrategy("random")
+	assert.Error(t, err)
+}
+
+func TestFreeSlots(t *testing.T) {
+	events := newEvents()
+	events.addAll(existingMeetings(
+		[2]time.Time{at(9, 0), at(10, 0)},
+		// overlapping the first meeting
+		[2]time.Time{at(9, 30), at(10, 30)},
+		[2]time.Time{at(12, 0), at(13, 0)},
+	))
+	p := &Plan{date: at(0, 0), events: events}
+
+	free, err := p.freeSlots(p.date)
+	assert.NoError(t, err)
+	assert.Equal(t, []Slot{
+		{StartTime: at(8, 0), EndTime: at(9, 0)},
+		{StartTime: at(10, 30), EndTime: at(12, 0)},
+		{StartTime: at(13, 0), EndTime: at(20, 0)},
+	}, free)
+}
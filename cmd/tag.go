@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var applyColors bool
+
+// recolored returns the events whose color differs from the color of the
+// rules they match
+func recolored(events []*calendar.Event, rules []rule) map[*calendar.Event]string {
+	changes := map[*calendar.Event]string{}
+	for _, e := range events {
+		if color := eventColor(e, rules); color != "" && color != e.ColorId {
+			changes[e] = color
+		}
+	}
+	return changes
+}
+
+// tagCmd shows the tags of the events and sets the colors of the rules
+var tagCmd = &cobra.Command{
+	Use:   "tag [DAY EXPRESSION]/[RANGE EXPRESSION]",
+	Short: "Show the tags the rules give to events, and color them",
+	Long: `Show the events of a day or a range of days with the tags of the rules in
+the config file. With --colors, set the color of the rules on the matching events.
+
+rules:
+  - tag: interview
+    title: (?i)interview
+    color: "11"
+  - tag: external
+    outsideDomain: example.com
+  - tag: 1:1
+    attendees: 2`,
+	Example: `$ calgo tag m-f
+$ calgo tag m-f --colors --yes`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		return validateDateExpressionArgs(args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, tmax, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		rules, err := loadRules()
+		if err != nil {
+			return err
+		}
+		srv := google_calendar.Service()
+		items, err := cachedEvents(srv, calendarID, midnight(tmin), midnight(tmax).AddDate(0, 0, 1))
+		if err != nil {
+			return fmt.Errorf("unable to retrieve events: %w", err)
+		}
+		for _, e := range items {
+			start, _, _ := eventTimes(e)
+			line := strings.TrimSuffix(eventString(e), "\n")
+			if tags := eventTags(e, rules); len(tags) > 0 {
+				line += " #" + strings.Join(tags, " #")
+			}
+			fmt.Printf("%s %s\n", start.Format(dayFormat), line)
+		}
+		if !applyColors {
+			return nil
+		}
+		changes := recolored(items, rules)
+		if len(changes) == 0 {
+			fmt.Println("All events have the colors of their rules.")
+			return nil
+		}
+		if ok, err := confirmChange(fmt.Sprintf("Color %d events?", len(changes))); err != nil || !ok {
+			return err
+		}
+		var failed int
+		for _, e := range items {
+			color, ok := changes[e]
+			if !ok {
+				continue
+			}
+			changed(calendarID)
+			err := withRetry(func() error {
+				_, err := srv.Events.Patch(calendarID, e.Id, &calendar.Event{ColorId: color}).Do()
+				return err
+			})
+			if err != nil {
+				failed++
+				fmt.Printf("failed coloring %q: %v\n", e.Summary, err)
+			}
+		}
+		fmt.Printf("Colored %d events\n", len(changes)-failed)
+		if failed > 0 {
+			return fmt.Errorf("failed coloring %d events", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	tagCmd.Flags().BoolVar(&applyColors, "colors", false, "set the color of the rules on the matching events")
+	tagCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "don't ask for confirmation, for scripting")
+	rootCmd.AddCommand(tagCmd)
+}
@@ -0,0 +1,530 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// moveStep is how much the arrow keys move an event in move mode
+const moveStep = 15 * time.Minute
+
+type tuiMode int
+
+const (
+	modeBrowse tuiMode = iota
+	modeConfirmDelete
+	modeMove
+	modePlan
+)
+
+// tuiBackend are the calendar operations of the terminal UI
+type tuiBackend struct {
+	events func(tmin, tmax time.Time) ([]*calendar.Event, error)
+	patch  func(e *calendar.Event, patch *calendar.Event) error
+	remove func(e *calendar.Event) error
+	// plan creates a plan of focus time for the day
+	plan   func(day time.Time) (*Plan, error)
+	commit func(p *Plan) (commitResults, error)
+}
+
+// tui is a full screen view of a day or a week of events
+type tui struct {
+	screen  tcell.Screen
+	backend tuiBackend
+	// day is the first day of the view
+	day      time.Time
+	week     bool
+	mode     tuiMode
+	events   []*calendar.Event
+	selected int
+	// moving is the new start of the selected event in move mode
+	moving time.Time
+	// plan is the plan of planning mode, its blocks are selected by block
+	plan    *Plan
+	block   int
+	message string
+	quit    bool
+}
+
+func newTui(screen tcell.Screen, backend tuiBackend, day time.Time) *tui {
+	return &tui{screen: screen, backend: backend, day: midnight(day)}
+}
+
+func (t *tui) days() int {
+	if t.week {
+		return 7
+	}
+	return 1
+}
+
+func (t *tui) reload() error {
+	events, err := t.backend.events(t.day, t.day.AddDate(0, 0, t.days()))
+	if err != nil {
+		return err
+	}
+	t.events = events
+	if t.selected >= len(t.events) {
+		t.selected = len(t.events) - 1
+	}
+	if t.selected < 0 {
+		t.selected = 0
+	}
+	return nil
+}
+
+func (t *tui) current() *calendar.Event {
+	if t.mode == modePlan {
+		blocks := t.plan.getAddedEvents()
+		if t.block < len(blocks) {
+			return blocks[t.block]
+		}
+		return nil
+	}
+	if t.selected < len(t.events) {
+		return t.events[t.selected]
+	}
+	return nil
+}
+
+// shown are the events in the list pane, the plan shows its proposed blocks
+// along with the existing events
+func (t *tui) shown() []*calendar.Event {
+	if t.mode == modePlan {
+		return t.plan.events.items()
+	}
+	return t.events
+}
+
+// report shows the error of an action, or the message if it succeeded
+func (t *tui) report(err error, message string) {
+	if err != nil {
+		t.message = err.Error()
+		return
+	}
+	t.message = message
+	if err := t.reload(); err != nil {
+		t.message = err.Error()
+	}
+}
+
+func (t *tui) handleKey(ev *tcell.EventKey) {
+	t.message = ""
+	switch t.mode {
+	case modeConfirmDelete:
+		t.mode = modeBrowse
+		if ev.Rune() == 'y' {
+			e := t.current()
+			t.report(t.backend.remove(e), "deleted "+e.Summary)
+		}
+	case modeMove:
+		t.handleMoveKey(ev)
+	case modePlan:
+		t.handlePlanKey(ev)
+	default:
+		t.handleBrowseKey(ev)
+	}
+}
+
+func (t *tui) handleBrowseKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		t.quit = true
+		return
+	case tcell.KeyLeft, tcell.KeyRight:
+		step := t.days()
+		if ev.Key() == tcell.KeyLeft {
+			step = -step
+		}
+		t.day = t.day.AddDate(0, 0, step)
+		t.selected = 0
+		t.report(nil, "")
+		return
+	case tcell.KeyUp:
+		if t.selected > 0 {
+			t.selected--
+		}
+		return
+	case tcell.KeyDown:
+		if t.selected < len(t.events)-1 {
+			t.selected++
+		}
+		return
+	}
+	switch ev.Rune() {
+	case 'q':
+		t.quit = true
+	case 'w':
+		t.week = !t.week
+		t.report(nil, "")
+	case 'p':
+		p, err := t.backend.plan(t.day)
+		if err == nil {
+			err = p.plan()
+		}
+		if err != nil {
+			t.message = err.Error()
+			return
+		}
+		t.plan, t.block, t.mode = p, 0, modePlan
+		t.message = fmt.Sprintf("Scheduled %s out of %s focus time", p.scheduledFocusTime, p.overallFocusTime)
+	}
+	e := t.current()
+	if e == nil {
+		return
+	}
+	switch ev.Rune() {
+	case 'a', 'd':
+		answer := "accept"
+		if ev.Rune() == 'd' {
+			answer = "decline"
+		}
+		patch, err := rsvpPatch(e, answer, "")
+		if err == nil {
+			err = t.backend.patch(e, patch)
+		}
+		t.report(err, responses[answer]+" "+e.Summary)
+	case 'x':
+		t.mode = modeConfirmDelete
+		t.message = fmt.Sprintf("Delete %q? y/n", e.Summary)
+	case 'm':
+		if e.Start.DateTime == "" {
+			t.message = "moving all-day events is not supported"
+			return
+		}
+		t.moving, _ = parseEventDateTime(e.Start)
+		t.mode = modeMove
+	}
+}
+
+func (t *tui) handleMoveKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyUp:
+		t.moving = t.moving.Add(-moveStep)
+	case tcell.KeyDown:
+		t.moving = t.moving.Add(moveStep)
+	case tcell.KeyLeft:
+		t.moving = t.moving.AddDate(0, 0, -1)
+	case tcell.KeyRight:
+		t.moving = t.moving.AddDate(0, 0, 1)
+	case tcell.KeyEnter:
+		t.mode = modeBrowse
+		e := t.current()
+		patch, err := movePatch(e, t.moving)
+		if err == nil {
+			err = t.backend.patch(e, patch)
+		}
+		t.report(err, "moved "+e.Summary+" to "+t.moving.Format(dayFormat+" "+time.Kitchen))
+	case tcell.KeyEscape:
+		t.mode = modeBrowse
+	}
+}
+
+func (t *tui) handlePlanKey(ev *tcell.EventKey) {
+	blocks := t.plan.getAddedEvents()
+	switch ev.Key() {
+	case tcell.KeyUp:
+		if t.block > 0 {
+			t.block--
+		}
+	case tcell.KeyDown:
+		if t.block < len(blocks)-1 {
+			t.block++
+		}
+	case tcell.KeyLeft, tcell.KeyRight:
+		if len(blocks) == 0 {
+			return
+		}
+		if !dragBlock(t.plan, blocks[t.block], ev.Key() == tcell.KeyRight) {
+			t.message = "no other free slot fits this block"
+		}
+		// the dragged block may have passed other blocks
+		for i, b := range t.plan.getAddedEvents() {
+			if b == blocks[t.block] {
+				t.block = i
+			}
+		}
+	case tcell.KeyEnter:
+		t.mode = modeBrowse
+		results, err := t.backend.commit(t.plan)
+		if err != nil {
+			err = fmt.Errorf("%w (%s)", err, results.summary())
+		}
+		t.report(err, "committed: "+results.summary())
+		t.plan = nil
+	case tcell.KeyEscape:
+		t.mode = modeBrowse
+		t.plan = nil
+		t.message = "plan discarded"
+	}
+}
+
+// dragBlock moves a proposed block of the plan to the next, or the previous,
+// free slot of its day which fits it. It returns false if there is none.
+func dragBlock(p *Plan, block *calendar.Event, forward bool) bool {
+	start, end, err := eventTimes(block)
+	if err != nil {
+		return false
+	}
+	// the free slots are measured without the block itself
+	for elm := p.events.Front(); elm != nil; elm = elm.Next() {
+		if elm.Value.(*calendar.Event) == block {
+			p.events.Remove(elm)
+			break
+		}
+	}
+	defer p.events.insert(block)
+	free, err := p.freeSlots(start)
+	if err != nil {
+		return false
+	}
+	var target time.Time
+	for _, slot := range free {
+		if slot.Duration() < end.Sub(start) {
+			continue
+		}
+		if forward && slot.StartTime.After(start) {
+			target = slot.StartTime
+			break
+		}
+		if !forward && slot.StartTime.Before(start) {
+			target = slot.StartTime
+		}
+	}
+	if target.IsZero() {
+		return false
+	}
+	block.Start = &calendar.EventDateTime{DateTime: target.Format(time.RFC3339)}
+	block.End = &calendar.EventDateTime{DateTime: target.Add(end.Sub(start)).Format(time.RFC3339)}
+	return true
+}
+
+// drawText draws the text from x up to width cells, and returns the next row
+func (t *tui) drawText(x, y, width int, style tcell.Style, text string) int {
+	col := 0
+	for _, r := range text {
+		if col >= width {
+			break
+		}
+		t.screen.SetContent(x+col, y, r, nil, style)
+		col++
+	}
+	return y + 1
+}
+
+func (t *tui) draw() {
+	t.screen.Clear()
+	w, h := t.screen.Size()
+	listWidth := w / 2
+	bold := tcell.StyleDefault.Bold(true)
+
+	title := t.day.Format(dayFormat)
+	if t.week {
+		title += " - " + t.day.AddDate(0, 0, 6).Format(dayFormat)
+	}
+	switch t.mode {
+	case modeMove:
+		title += "  [move to " + t.moving.Format(dayFormat+" "+time.Kitchen) + "]"
+	case modePlan:
+		title += "  [plan]"
+	}
+	t.drawText(0, 0, w, bold, title)
+
+	current := t.current()
+	y := 2
+	var lastDay string
+	for _, e := range t.shown() {
+		if y >= h-1 {
+			break
+		}
+		start, _, _ := eventTimes(e)
+		if day := start.Format(dayFormat); t.week && day != lastDay {
+			y = t.drawText(0, y, listWidth, bold, day)
+			lastDay = day
+		}
+		style := tcell.StyleDefault
+		if e.Id == "" {
+			style = style.Foreground(tcell.ColorGreen)
+		}
+		if e == current {
+			style = style.Reverse(true)
+		}
+		y = t.drawText(0, y, listWidth-1, style, strings.TrimSuffix(eventString(e), "\n"))
+	}
+	if len(t.shown()) == 0 {
+		t.drawText(0, y, listWidth-1, tcell.StyleDefault, "No events.")
+	}
+	if current != nil {
+		t.drawDetails(listWidth+1, 2, w-listWidth-1, h-3, current)
+	}
+
+	status := t.message
+	if status == "" {
+		status = t.help()
+	}
+	t.drawText(0, h-1, w, tcell.StyleDefault.Dim(true), status)
+	t.screen.Show()
+}
+
+func (t *tui) help() string {
+	switch t.mode {
+	case modeMove:
+		return "↑↓ 15 minutes  ←→ day  enter move  esc cancel"
+	case modePlan:
+		return "↑↓ select block  ←→ drag to free slot  enter commit  esc discard"
+	}
+	return "←→ day/week  ↑↓ select  w week  a accept  d decline  x delete  m move  p plan  q quit"
+}
+
+func (t *tui) drawDetails(x, y, width, height int, e *calendar.Event) {
+	bold := tcell.StyleDefault.Bold(true)
+	var lines []string
+	lines = append(lines, strings.TrimSpace(eventString(e)))
+	if e.Organizer != nil && e.Organizer.Email != "" {
+		lines = append(lines, "organizer: "+e.Organizer.Email)
+	}
+	if len(e.Attendees) > 0 {
+		lines = append(lines, "attendees:")
+		for _, a := range e.Attendees {
+			lines = append(lines, fmt.Sprintf("  %s (%s)", a.Email, a.ResponseStatus))
+		}
+	}
+	if e.HangoutLink != "" {
+		lines = append(lines, "meet: "+e.HangoutLink)
+	}
+	if e.Location != "" {
+		lines = append(lines, "location: "+e.Location)
+	}
+	if e.Description != "" {
+		lines = append(lines, "")
+		lines = append(lines, wrap(e.Description, width)...)
+	}
+	y = t.drawText(x, y, width, bold, e.Summary)
+	for _, line := range lines {
+		if height--; height <= 0 {
+			break
+		}
+		y = t.drawText(x, y, width, tcell.StyleDefault, line)
+	}
+}
+
+// wrap splits the text to lines of up to width characters
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// run handles the keys until quitting
+func (t *tui) run() error {
+	if err := t.reload(); err != nil {
+		t.message = err.Error()
+	}
+	for !t.quit {
+		t.draw()
+		switch ev := t.screen.PollEvent().(type) {
+		case nil:
+			// the screen was closed
+			return nil
+		case *tcell.EventResize:
+			t.screen.Sync()
+		case *tcell.EventKey:
+			t.handleKey(ev)
+		}
+	}
+	return nil
+}
+
+func newTuiBackend(srv *calendar.Service) tuiBackend {
+	return tuiBackend{
+		events: func(tmin, tmax time.Time) ([]*calendar.Event, error) {
+			// the view reflects the changes made in it right away
+			if offline {
+				return cachedEvents(srv, calendarID, tmin, tmax)
+			}
+			return apiEvents(srv, calendarID, tmin, tmax, "")
+		},
+		patch: func(e *calendar.Event, patch *calendar.Event) error {
+			changed(calendarID)
+			return withRetry(func() error {
+				_, err := srv.Events.Patch(calendarID, e.Id, patch).SendUpdates("all").Do()
+				return err
+			})
+		},
+		remove: func(e *calendar.Event) error {
+			changed(calendarID)
+			return withRetry(func() error {
+				return srv.Events.Delete(calendarID, e.Id).SendUpdates("all").Do()
+			})
+		},
+		plan: func(day time.Time) (*Plan, error) {
+			return newPlan(calendarID, srv, startOfDay(day), endOfDay(day))
+		},
+		commit: func(p *Plan) (commitResults, error) {
+			// the view confirms, and shows a summary of the results
+			p.confirmed = true
+			return p.commit()
+		},
+	}
+}
+
+// tuiCmd is a full screen terminal UI
+var tuiCmd = &cobra.Command{
+	Use:   "tui [DAY EXPRESSION]",
+	Short: "Browse and plan in a full screen terminal UI",
+	Long: `Browse the events of a day or a week with the arrow keys, see their
+attendees, description and meet link, accept, decline, delete or move them.
+In planning mode the proposed focus blocks are dragged between the free slots
+with the arrow keys before committing them.`,
+	Example: "$ calgo tui m",
+	Args: func(cmd *cobra.Command, args []string) error {
+		return validateDateExpressionArgs(args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, _, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		focusSettings, err = loadFocusSettings()
+		if err != nil {
+			return err
+		}
+		screen, err := tcell.NewScreen()
+		if err != nil {
+			return err
+		}
+		if err := screen.Init(); err != nil {
+			return err
+		}
+		defer screen.Fini()
+		// logs would scramble the screen
+		log.SetOutput(io.Discard)
+		return newTui(screen, newTuiBackend(google_calendar.Service()), tmin).run()
+	},
+}
+
+func init() {
+	tuiCmd.Flags().DurationVar(&focusTime, "focus-time", time.Minute*45, "overall focus time to plan in planning mode (e.g 2h)")
+	rootCmd.AddCommand(tuiCmd)
+}
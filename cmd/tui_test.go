@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+// fakeTuiBackend keeps the events in memory
+type fakeTuiBackend struct {
+	events    []*calendar.Event
+	patched   map[string]*calendar.Event
+	removed   []string
+	committed []*calendar.Event
+	// planErr fails reading the events to plan
+	planErr error
+	// commitErr fails creating the first of the committed events
+	commitErr error
+}
+
+func (f *fakeTuiBackend) backend() tuiBackend {
+	return tuiBackend{
+		events: func(tmin, tmax time.Time) ([]*calendar.Event, error) {
+			return overlappingAll(f.events, tmin, tmax), nil
+		},
+		patch: func(e *calendar.Event, patch *calendar.Event) error {
+			f.patched[e.Id] = patch
+			return nil
+		},
+		remove: func(e *calendar.Event) error {
+			f.removed = append(f.removed, e.Id)
+			return nil
+		},
+		plan: func(day time.Time) (*Plan, error) {
+			if f.planErr != nil {
+				return nil, f.planErr
+			}
+			events := newEvents()
+			events.addAll(f.events)
+			return &Plan{
+				date:             day,
+				events:           events,
+				overallFocusTime: time.Hour,
+				focusDuration:    time.Hour,
+				now:              fixedClock(at(7, 0)),
+			}, nil
+		},
+		commit: func(p *Plan) (commitResults, error) {
+			f.committed = p.getAddedEvents()
+			var results commitResults
+			for _, e := range f.committed {
+				results = append(results, &commitResult{event: e, status: statusCreated})
+			}
+			if f.commitErr != nil {
+				results[0].status, results[0].err = statusFailed, f.commitErr
+			}
+			return results, f.commitErr
+		},
+	}
+}
+
+// overlappingAll returns the events overlapping tmin to tmax, busy or not
+func overlappingAll(events []*calendar.Event, tmin, tmax time.Time) []*calendar.Event {
+	var in []*calendar.Event
+	for _, e := range events {
+		start, end, _ := eventTimes(e)
+		if start.Before(tmax) && end.After(tmin) {
+			in = append(in, e)
+		}
+	}
+	return in
+}
+
+// screenText returns the rows of the virtual terminal
+func screenText(s tcell.SimulationScreen) string {
+	cells, w, _ := s.GetContents()
+	var b strings.Builder
+	for i, c := range cells {
+		if len(c.Runes) > 0 {
+			b.WriteRune(c.Runes[0])
+		} else {
+			b.WriteRune(' ')
+		}
+		if (i+1)%w == 0 {
+			b.WriteRune('\n')
+		}
+	}
+	return b.String()
+}
+
+func newTestTui(t *testing.T) (*tui, *fakeTuiBackend, tcell.SimulationScreen) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	t.Cleanup(screen.Fini)
+	screen.SetSize(120, 30)
+	meetings := existingMeetings(
+		[2]time.Time{at(9, 0), at(10, 0)},
+		[2]time.Time{at(11, 0), at(12, 0)},
+	)
+	meetings[1].Attendees = []*calendar.EventAttendee{
+		{Email: "dana@example.com", ResponseStatus: "accepted"},
+		{Email: "me@example.com", Self: true, ResponseStatus: "needsAction"},
+	}
+	meetings[1].HangoutLink = "https://meet.google.com/abc"
+	meetings[1].Description = "agenda for the design review"
+	fake := &fakeTuiBackend{events: meetings, patched: map[string]*calendar.Event{}}
+	ui := newTui(screen, fake.backend(), at(0, 0))
+	assert.NoError(t, ui.reload())
+	return ui, fake, screen
+}
+
+func key(k tcell.Key) *tcell.EventKey {
+	return tcell.NewEventKey(k, 0, tcell.ModNone)
+}
+
+func runeKey(r rune) *tcell.EventKey {
+	return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+}
+
+func TestTuiBrowse(t *testing.T) {
+	ui, fake, screen := newTestTui(t)
+	ui.draw()
+	text := screenText(screen)
+	assert.Contains(t, text, "Mon 25 Sep")
+	assert.Contains(t, text, "meeting 1")
+	assert.Contains(t, text, "meeting 2")
+
+	ui.handleKey(key(tcell.KeyDown))
+	ui.draw()
+	text = screenText(screen)
+	assert.Contains(t, text, "dana@example.com (accepted)")
+	assert.Contains(t, text, "meet: https://meet.google.com/abc")
+	assert.Contains(t, text, "agenda for the design review")
+
+	ui.handleKey(runeKey('a'))
+	assert.Equal(t, "accepted", fake.patched["2"].Attendees[1].ResponseStatus)
+
+	ui.handleKey(runeKey('x'))
+	assert.Contains(t, ui.message, `Delete "meeting 2"?`)
+	ui.handleKey(runeKey('n'))
+	assert.Empty(t, fake.removed)
+	ui.handleKey(runeKey('x'))
+	ui.handleKey(runeKey('y'))
+	assert.Equal(t, []string{"2"}, fake.removed)
+
+	ui.handleKey(key(tcell.KeyRight))
+	ui.draw()
+	assert.Contains(t, screenText(screen), "Tue 26 Sep")
+	assert.Contains(t, screenText(screen), "No events.")
+	ui.handleKey(runeKey('w'))
+	ui.draw()
+	assert.Contains(t, screenText(screen), "Tue 26 Sep - Mon 02 Oct")
+}
+
+func TestTuiMove(t *testing.T) {
+	ui, fake, _ := newTestTui(t)
+	ui.handleKey(runeKey('m'))
+	ui.handleKey(key(tcell.KeyDown))
+	ui.handleKey(key(tcell.KeyDown))
+	ui.handleKey(key(tcell.KeyRight))
+	ui.handleKey(key(tcell.KeyEnter))
+	assert.Equal(t, at(9, 30).AddDate(0, 0, 1).Format(time.RFC3339), fake.patched["1"].Start.DateTime)
+	assert.Equal(t, modeBrowse, ui.mode)
+}
+
+func TestTuiPlanDragBlocks(t *testing.T) {
+	ui, fake, screen := newTestTui(t)
+	ui.handleKey(runeKey('p'))
+	assert.Equal(t, modePlan, ui.mode)
+	blockStart := func() string { return ui.plan.getAddedEvents()[0].Start.DateTime }
+	// free: 08:00-09:00, 10:00-11:00, 12:00-20:00
+	assert.Equal(t, at(8, 0).Format(time.RFC3339), blockStart())
+
+	ui.handleKey(key(tcell.KeyRight))
+	assert.Equal(t, at(10, 0).Format(time.RFC3339), blockStart())
+	ui.handleKey(key(tcell.KeyRight))
+	assert.Equal(t, at(12, 0).Format(time.RFC3339), blockStart())
+	ui.handleKey(key(tcell.KeyRight))
+	assert.Equal(t, at(12, 0).Format(time.RFC3339), blockStart())
+	assert.Contains(t, ui.message, "no other free slot")
+	ui.handleKey(key(tcell.KeyLeft))
+	assert.Equal(t, at(10, 0).Format(time.RFC3339), blockStart())
+
+	ui.draw()
+	assert.Contains(t, screenText(screen), "[plan]")
+	ui.handleKey(key(tcell.KeyEnter))
+	assert.Len(t, fake.committed, 1)
+	assert.Equal(t, at(10, 0).Format(time.RFC3339), fake.committed[0].Start.DateTime)
+	assert.Equal(t, modeBrowse, ui.mode)
+	assert.Equal(t, "committed: 1 created", ui.message)
+}
+
+func TestTuiCommitFailure(t *testing.T) {
+	ui, fake, screen := newTestTui(t)
+	fake.commitErr = fmt.Errorf("unable to create event \"Focus Time\": quota exceeded")
+
+	ui.handleKey(runeKey('p'))
+	ui.handleKey(key(tcell.KeyEnter))
+	ui.draw()
+	assert.Equal(t, modeBrowse, ui.mode)
+	assert.Contains(t, screenText(screen), "quota exceeded (1 failed)")
+}
+
+func TestTuiPlanFailure(t *testing.T) {
+	ui, fake, screen := newTestTui(t)
+	fake.planErr = fmt.Errorf("unable to retrieve the events to plan: offline")
+
+	ui.handleKey(runeKey('p'))
+	ui.draw()
+	assert.Equal(t, modeBrowse, ui.mode)
+	assert.Contains(t, screenText(screen), "unable to retrieve the events to plan: offline")
+}
+
+func TestTuiRun(t *testing.T) {
+	ui, _, screen := newTestTui(t)
+	screen.InjectKey(tcell.KeyDown, 0, tcell.ModNone)
+	screen.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	done := make(chan error)
+	go func() { done <- ui.run() }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the ui did not quit")
+	}
+	assert.Equal(t, 1, ui.selected)
+}
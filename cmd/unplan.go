@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// unplanCmd removes the events calgo created
+var unplanCmd = &cobra.Command{
+	Use:   "unplan [DAY EXPRESSION]/[RANGE EXPRESSION]",
+	Short: "Remove the events calgo created",
+	Long: `Remove all the events calgo created on a day or a range of days.
+Events created by other means are never touched.`,
+	Example: "$ calgo unplan m-f",
+	Args: func(cmd *cobra.Command, args []string) error {
+		return validateDateExpressionArgs(args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, tmax, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		srv := google_calendar.Service()
+		events, err := listCalgoEvents(srv, calendarID, midnight(tmin), midnight(tmax).AddDate(0, 0, 1))
+		if err != nil {
+			return fmt.Errorf("unable to retrieve events: %w", err)
+		}
+		return unplan(events, func(id string) error {
+			changed(calendarID)
+			return srv.Events.Delete(calendarID, id).SendUpdates("all").Do()
+		})
+	},
+}
+
+// listCalgoEvents lists the events calgo created from tmin to tmax, all the
+// pages of them
+func listCalgoEvents(service *calendar.Service, calendarId string, tmin, tmax time.Time) ([]*calendar.Event, error) {
+	call := service.Events.List(calendarId).
+		ShowDeleted(false).
+		SingleEvents(true).
+		PrivateExtendedProperty(calgoVersionProperty + "=" + calgoVersion).
+		TimeMin(tmin.Format(time.RFC3339)).
+		TimeMax(tmax.Format(time.RFC3339)).
+		OrderBy(sortField)
+	var events []*calendar.Event
+	err := withRetry(func() error {
+		events = nil
+		return call.Pages(context.Background(), func(page *calendar.Events) error {
+			events = append(events, page.Items...)
+			return nil
+		})
+	})
+	return calgoEvents(events), err
+}
+
+func calgoEvents(events []*calendar.Event) []*calendar.Event {
+	var owned []*calendar.Event
+	for _, e := range events {
+		if isCalgoEvent(e) {
+			owned = append(owned, e)
+		}
+	}
+	return owned
+}
+
+func unplan(events []*calendar.Event, eventDeleter func(id string) error) error {
+	if len(events) == 0 {
+		fmt.Println("No events created by calgo.")
+		return nil
+	}
+	for _, e := range events {
+		start, _, _ := eventTimes(e)
+		fmt.Printf("%s %s", start.Format(dayFormat), eventString(e))
+	}
+	if interactive {
+		remove, err := confirm(fmt.Sprintf("Remove these %d events?", len(events)), false)
+		if err != nil || !remove {
+			return err
+		}
+	}
+	var results commitResults
+	var failure error
+	for _, e := range events {
+		result := &commitResult{event: e, status: statusDeleted}
+		results = append(results, result)
+		result.err = withRetry(func() error {
+			return eventDeleter(e.Id)
+		})
+		if result.err != nil {
+			result.status = statusFailed
+			failure = fmt.Errorf("unable to delete event %q: %w", e.Summary, result.err)
+		}
+	}
+	fmt.Print(results)
+	return failure
+}
+
+func init() {
+	unplanCmd.Flags().BoolVar(&interactive, "interactive", true, "Ask before removing the events")
+	rootCmd.AddCommand(unplanCmd)
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/rgolangh/calgo/internal/google_calendar"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var workingLocationLabel string
+
+// workingLocationTypes maps the where locations to the calendar API types
+var workingLocationTypes = map[string]string{
+	"home":   "homeOffice",
+	"office": "officeLocation",
+	"custom": "customLocation",
+}
+
+// newWorkingLocationEvent creates an all-day working location event. The
+// location is one of workingLocationTypes, and custom locations must have a
+// label.
+func newWorkingLocationEvent(day string, location, label string) *calendar.Event {
+	properties := &calendar.EventWorkingLocationProperties{Type: workingLocationTypes[location]}
+	summary := "Home"
+	switch location {
+	case "home":
+		properties.HomeOffice = map[string]interface{}{}
+	case "office":
+		properties.OfficeLocation = &calendar.EventWorkingLocationPropertiesOfficeLocation{Label: label}
+		summary = "Office"
+	case "custom":
+		properties.CustomLocation = &calendar.EventWorkingLocationPropertiesCustomLocation{Label: label}
+	}
+	if label != "" {
+		summary = label
+	}
+	return stampKind(&calendar.Event{
+		Summary:                   summary,
+		EventType:                 "workingLocation",
+		Visibility:                "public",
+		Transparency:              "transparent",
+		WorkingLocationProperties: properties,
+		Start:                     &calendar.EventDateTime{Date: day},
+		End:                       &calendar.EventDateTime{Date: nextDate(day)},
+	}, kindWorkingLocation)
+}
+
+func workingLocationString(p *calendar.EventWorkingLocationProperties) string {
+	if p == nil {
+		return "@?"
+	}
+	switch p.Type {
+	case "homeOffice":
+		return "@home"
+	case "officeLocation":
+		if p.OfficeLocation != nil && p.OfficeLocation.Label != "" {
+			return "@office: " + p.OfficeLocation.Label
+		}
+		return "@office"
+	case "customLocation":
+		if p.CustomLocation != nil {
+			return "@" + p.CustomLocation.Label
+		}
+	}
+	return "@" + p.Type
+}
+
+// whereCmd sets the working location of a day
+var whereCmd = &cobra.Command{
+	Use:   "where DAY EXPRESSION home|office|custom",
+	Short: "Set where you work from on a day",
+	Example: `$ calgo where th home
+$ calgo where m office --label "TLV 5th floor"
+$ calgo where +1 custom --label "Berlin offsite"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("expected a day expression and a location")
+		}
+		if _, ok := workingLocationTypes[args[1]]; !ok {
+			return fmt.Errorf("location must be one of %s", keys(workingLocationTypes))
+		}
+		if args[1] == "custom" && workingLocationLabel == "" {
+			return fmt.Errorf("a custom location must have a --label")
+		}
+		return validateDateExpressionArgs(args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmin, tmax, err := getTimeBoundaries(args)
+		if err != nil {
+			return err
+		}
+		if !sameDay(tmin, tmax) {
+			return fmt.Errorf("set the location of a single day")
+		}
+		srv := google_calendar.Service()
+		p := eventsPlan(calendarID, srv, []*calendar.Event{
+			newWorkingLocationEvent(tmin.Format(allDayFormat), args[1], workingLocationLabel),
+		})
+		p.date = tmin
+		log.Println(p)
+		results, err := p.commit()
+		fmt.Print(results)
+		return err
+	},
+}
+
+func init() {
+	whereCmd.Flags().StringVar(&workingLocationLabel, "label", "", "name of the location, e.g the office building")
+	whereCmd.Flags().BoolVar(&interactive, "interactive", true, "Ask before committing changes")
+	rootCmd.AddCommand(whereCmd)
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkingLocationEvent(t *testing.T) {
+	cases := []struct {
+		location string
+		label    string
+		wanted   string
+	}{
+		{location: "home", wanted: "[@home] Home"},
+		{location: "office", label: "TLV", wanted: "[@office: TLV] TLV"},
+		{location: "custom", label: "Berlin offsite", wanted: "[@Berlin offsite] Berlin offsite"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.location, func(t *testing.T) {
+			e := newWorkingLocationEvent("2023-09-25", tc.location, tc.label)
+			assert.Equal(t, "2023-09-26", e.End.Date)
+			assert.Equal(t, workingLocationTypes[tc.location], e.WorkingLocationProperties.Type)
+			assert.False(t, isBusy(e), "working location does not block time")
+			assert.Contains(t, eventString(e), "all day")
+			assert.Contains(t, eventString(e), tc.wanted)
+		})
+	}
+}
+
+func TestPlanIgnoresWorkingLocation(t *testing.T) {
+	events := newEvents()
+	location := newWorkingLocationEvent("2023-09-25", "home", "")
+	location.Id = "wl"
+	events.insert(location)
+	p := &Plan{
+		date:             time.Date(2023, 9, 25, 0, 0, 0, 0, time.Local),
+		overallFocusTime: time.Hour,
+		focusDuration:    time.Hour,
+		events:           events,
+	}
+
+	assert.NoError(t, p.plan())
+	assert.Len(t, p.getAddedEvents(), 1)
+}
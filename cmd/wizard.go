@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"google.golang.org/api/calendar/v3"
+)
+
+// parsePositiveDuration parses a duration which must be greater than 0 and,
+// unless max is zero, at most max
+func parsePositiveDuration(s string, max time.Duration) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("unsupported duration %q, use the form of 45m or 1h30m", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be greater than 0")
+	}
+	if max > 0 && d > max {
+		return 0, fmt.Errorf("duration must be at most %s", shortDuration(max))
+	}
+	return d, nil
+}
+
+// shortDuration formats a duration without its zero units, e.g 50m
+// rather than 50m0s
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// askDuration asks for a duration until a valid one is given
+func askDuration(message string, defaultDuration, max time.Duration) (time.Duration, error) {
+	var answer string
+	err := askOne(&survey.Input{Message: message, Default: shortDuration(defaultDuration)}, &answer,
+		survey.WithValidator(func(ans interface{}) error {
+			_, err := parsePositiveDuration(ans.(string), max)
+			return err
+		}))
+	if err != nil {
+		return 0, err
+	}
+	return parsePositiveDuration(answer, max)
+}
+
+// validateOptionalAttendee accepts an email address or an alias, or
+// nothing to end the list
+func validateOptionalAttendee(aliases map[string][]string) survey.Validator {
+	return func(ans interface{}) error {
+		_, err := expandAttendees([]string{ans.(string)}, aliases)
+		return err
+	}
+}
+
+// askAttendees asks for attendees one by one, completed out of the address
+// book, until an empty answer. Aliases expand into their members.
+func askAttendees(message string, book *addressBook) ([]*calendar.EventAttendee, error) {
+	var attendees []*calendar.EventAttendee
+	for {
+		var answer string
+		err := askOne(&survey.Input{Message: message, Suggest: book.complete}, &answer,
+			survey.WithValidator(validateOptionalAttendee(book.aliases)))
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(answer) == "" {
+			return attendees, nil
+		}
+		emails, err := expandAttendees([]string{answer}, book.aliases)
+		if err != nil {
+			return nil, err
+		}
+		for _, email := range emails {
+			attendees = append(attendees, &calendar.EventAttendee{Email: email})
+		}
+	}
+}
+
+// surveyFocus asks for the focus interval, up to the overall focus time,
+// and the title of the focus events. Either is skipped when it was given
+// with a flag.
+func surveyFocus(p *Plan, askInterval, askTitle bool) error {
+	if askInterval {
+		max := p.overallFocusTime
+		if max <= 0 {
+			max = p.focusDuration
+		}
+		duration, err := askDuration("[focus time] duration for each interval?", minDuration(p.focusDuration, max), max)
+		if err != nil {
+			return err
+		}
+		p.focusDuration = duration
+		if p.minFocusDuration > duration {
+			p.minFocusDuration = duration
+		}
+	}
+	if !askTitle {
+		return nil
+	}
+	err := askOne(&survey.Input{Message: "[focus time] optional event name?", Default: focusSettings.Title}, &focusSettings.Title)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(focusSettings.Title) == "" {
+		focusSettings.Title = defaultFocus().Title
+	}
+	return nil
+}
+
+// surveyMeetings asks for meetings until their durations add up to the
+// overall meetings time. Meetings given with --meeting count in.
+func surveyMeetings(p *Plan, overall time.Duration, book *addressBook) error {
+	var planned time.Duration
+	for _, m := range p.meetings {
+		planned += m.Duration
+	}
+	for n := len(p.meetings) + 1; planned < overall; n++ {
+		prefix := fmt.Sprintf("[meeting %d]", n)
+		m := Meeting{}
+		err := askOne(&survey.Input{Message: prefix + " event name?"}, &m.Title, survey.WithValidator(survey.Required))
+		if err != nil {
+			return err
+		}
+		remaining := overall - planned
+		m.Duration, err = askDuration(prefix+" duration?", minDuration(defaultMeetingDuration, remaining), remaining)
+		if err != nil {
+			return err
+		}
+		m.Attendees, err = askAttendees(prefix+" attendees (tab to autocomplete, enter on empty to finish):", book)
+		if err != nil {
+			return err
+		}
+		p.meetings = append(p.meetings, m)
+		planned += m.Duration
+	}
+	return nil
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/calendar/v3"
+)
+
+// scriptedAnswers replaces askOne with answers given in order, the
+// validators must accept each answer. Confirmations are answered with y or
+// n, an empty answer takes the default of any prompt.
+func scriptedAnswers(t *testing.T, answers ...string) *[]string {
+	t.Helper()
+	var asked []string
+	original := askOne
+	t.Cleanup(func() { askOne = original })
+	askOne = func(p survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+		var message string
+		switch prompt := p.(type) {
+		case *survey.Input:
+			message = prompt.Message
+		case *survey.Confirm:
+			message = prompt.Message
+		default:
+			t.Fatalf("unexpected prompt %T", p)
+		}
+		asked = append(asked, message)
+		if len(answers) == 0 {
+			return fmt.Errorf("unexpected question %q", message)
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		if prompt, ok := p.(*survey.Confirm); ok {
+			switch answer {
+			case "":
+				*response.(*bool) = prompt.Default
+			case "y", "n":
+				*response.(*bool) = answer == "y"
+			default:
+				t.Fatalf("answer %q to %q is not y or n", answer, message)
+			}
+			return nil
+		}
+		if answer == "" {
+			answer = p.(*survey.Input).Default
+		}
+		options := survey.AskOptions{}
+		for _, opt := range opts {
+			assert.NoError(t, opt(&options))
+		}
+		for _, v := range options.Validators {
+			if err := v(answer); err != nil {
+				return fmt.Errorf("answer %q to %q: %w", answer, message, err)
+			}
+		}
+		*response.(*string) = answer
+		return nil
+	}
+	return &asked
+}
+
+func TestScriptedConfirm(t *testing.T) {
+	asked := scriptedAnswers(t, "y", "")
+
+	yes, err := confirm("Commit?", false)
+	assert.NoError(t, err)
+	assert.True(t, yes)
+	no, err := confirm("Rollback?", false)
+	assert.NoError(t, err)
+	assert.False(t, no)
+	assert.Equal(t, []string{"Commit?", "Rollback?"}, *asked)
+}
+
+func TestParsePositiveDuration(t *testing.T) {
+	d, err := parsePositiveDuration("1h30m", 2*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	_, err = parsePositiveDuration("0", 0)
+	assert.EqualError(t, err, "duration must be greater than 0")
+	_, err = parsePositiveDuration("-45m", 0)
+	assert.EqualError(t, err, "duration must be greater than 0")
+	_, err = parsePositiveDuration("3h", 2*time.Hour)
+	assert.EqualError(t, err, "duration must be at most 2h")
+	_, err = parsePositiveDuration("45", 0)
+	assert.Error(t, err)
+}
+
+func TestShortDuration(t *testing.T) {
+	assert.Equal(t, "50m", shortDuration(50*time.Minute))
+	assert.Equal(t, "2h", shortDuration(2*time.Hour))
+	assert.Equal(t, "1h30m", shortDuration(90*time.Minute))
+	assert.Equal(t, "30s", shortDuration(30*time.Second))
+}
+
+func TestSurveyFocus(t *testing.T) {
+	defer func(f Focus) { focusSettings = f }(focusSettings)
+	focusSettings = defaultFocus()
+	scriptedAnswers(t, "25m", "create calgo")
+	p := &Plan{overallFocusTime: 5 * time.Hour, focusDuration: 45 * time.Minute, minFocusDuration: 30 * time.Minute}
+
+	assert.NoError(t, surveyFocus(p, true, true))
+	assert.Equal(t, 25*time.Minute, p.focusDuration)
+	assert.Equal(t, 25*time.Minute, p.minFocusDuration)
+	assert.Equal(t, "create calgo", focusSettings.Title)
+}
+
+func TestSurveyFocusSkipsFlags(t *testing.T) {
+	defer func(f Focus) { focusSettings = f }(focusSettings)
+	focusSettings = defaultFocus()
+	asked := scriptedAnswers(t, "create calgo")
+	p := &Plan{overallFocusTime: 5 * time.Hour, focusDuration: time.Hour, minFocusDuration: 30 * time.Minute}
+
+	assert.NoError(t, surveyFocus(p, false, true))
+	assert.Equal(t, []string{"[focus time] optional event name?"}, *asked)
+	assert.Equal(t, time.Hour, p.focusDuration)
+
+	assert.NoError(t, surveyFocus(p, false, false))
+	assert.Len(t, *asked, 1, "nothing is asked when all is given")
+}
+
+func TestSurveyFocusRejectsInvalidDurations(t *testing.T) {
+	for _, answer := range []string{"0", "-1h", "6h", "soon"} {
+		scriptedAnswers(t, answer)
+		p := &Plan{overallFocusTime: 5 * time.Hour, focusDuration: 45 * time.Minute}
+		assert.Error(t, surveyFocus(p, true, true), answer)
+	}
+}
+
+func TestSurveyMeetings(t *testing.T) {
+	asked := scriptedAnswers(t,
+		"discuss new requirements", "", "dana@example.com", "joe@example.com", " ",
+		"retro", "", " ",
+	)
+	p := &Plan{meetings: []Meeting{{Title: "standup", Duration: 15 * time.Minute}}}
+
+	assert.NoError(t, surveyMeetings(p, 90*time.Minute, newAddressBook()))
+	assert.Equal(t, []Meeting{
+		{Title: "standup", Duration: 15 * time.Minute},
+		{Title: "discuss new requirements", Duration: 50 * time.Minute, Attendees: []*calendar.EventAttendee{
+			{Email: "dana@example.com"},
+			{Email: "joe@example.com"},
+		}},
+		// only 25m are left out of the meetings time
+		{Title: "retro", Duration: 25 * time.Minute},
+	}, p.meetings)
+	assert.True(t, strings.HasPrefix((*asked)[0], "[meeting 2]"))
+}
+
+func TestSurveyMeetingsValidatesAttendees(t *testing.T) {
+	scriptedAnswers(t, "retro", "30m", "dana")
+	p := &Plan{}
+	assert.ErrorContains(t, surveyMeetings(p, time.Hour, newAddressBook()), `"dana" is neither an alias nor an email address`)
+}
+
+func TestSurveyMeetingsExpandsAliases(t *testing.T) {
+	scriptedAnswers(t, "retro", "30m", "team", "ann@example.com", "")
+	book := newAddressBook()
+	book.aliases["team"] = []string{"dana@example.com", "joe@example.com"}
+	p := &Plan{}
+
+	assert.NoError(t, surveyMeetings(p, 30*time.Minute, book))
+	assert.Equal(t, []*calendar.EventAttendee{
+		{Email: "dana@example.com"},
+		{Email: "joe@example.com"},
+		{Email: "ann@example.com"},
+	}, p.meetings[0].Attendees)
+}
@@ -9,32 +9,63 @@ import (
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
 	"google.golang.org/api/option"
+	"google.golang.org/api/people/v1"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 )
 
+// ContactsScopes are granted with calgo init --contacts, for completing
+// attendees out of the contacts and the directory of the organization
+var ContactsScopes = []string{
+	people.ContactsReadonlyScope,
+	people.ContactsOtherReadonlyScope,
+	people.DirectoryReadonlyScope,
+}
+
+// tokenFile stores the access and refresh tokens of the user
+const tokenFile = "token.json"
+
 func Service() *calendar.Service {
-	ctx := context.Background()
-	b, err := ioutil.ReadFile("credentials.json")
+	srv, err := calendar.NewService(context.Background(), option.WithHTTPClient(client()))
 	if err != nil {
-		log.Fatalf("Unable to read client secret file: %v", err)
+		log.Fatalf("Unable to retrieve Calendar client: %v", err)
 	}
+	return srv
+}
 
-	// If modifying these scopes, delete your previously saved token.json.
-	config, err := google.ConfigFromJSON(b, calendar.CalendarScope)
+// PeopleService is the contacts and directory client. The calls fail with
+// 403 unless the ContactsScopes were granted.
+func PeopleService() *people.Service {
+	srv, err := people.NewService(context.Background(), option.WithHTTPClient(client()))
 	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+		log.Fatalf("Unable to retrieve People client: %v", err)
 	}
+	return srv
+}
 
-	client := getClient(config)
+// Authorize drops the saved token and asks for a new one with the calendar
+// scope and the given extra scopes
+func Authorize(scopes ...string) {
+	if err := os.Remove(tokenFile); err != nil && !os.IsNotExist(err) {
+		log.Fatalf("Unable to remove the saved token: %v", err)
+	}
+	client(scopes...)
+}
 
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+func client(scopes ...string) *http.Client {
+	b, err := ioutil.ReadFile("credentials.json")
 	if err != nil {
-		log.Fatalf("Unable to retrieve Calendar client: %v", err)
+		log.Fatalf("Unable to read client secret file: %v", err)
 	}
-	return srv
+
+	// If modifying these scopes, delete your previously saved token.json.
+	config, err := google.ConfigFromJSON(b, append([]string{calendar.CalendarScope}, scopes...)...)
+	if err != nil {
+		log.Fatalf("Unable to parse client secret file to config: %v", err)
+	}
+	return getClient(config)
 }
 
 func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
@@ -70,11 +101,10 @@ func getClient(config *oauth2.Config) *http.Client {
 	// The file token.json stores the user's access and refresh tokens, and is
 	// created automatically when the authorization flow completes for the first
 	// time.
-	tokFile := "token.json"
-	tok, err := tokenFromFile(tokFile)
+	tok, err := tokenFromFile(tokenFile)
 	if err != nil {
 		tok = getTokenFromWeb(config)
-		saveToken(tokFile, tok)
+		saveToken(tokenFile, tok)
 	}
 	return config.Client(context.Background(), tok)
 }